@@ -3,6 +3,8 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"math/rand"
 	"os"
@@ -30,20 +32,48 @@ var (
 
 	keepTemp bool
 
+	writeIR bool
+	fromIR  string
+
+	tmplFile string
+	tmplText string
+
 	tmpl = template.Must(template.New("").Parse(fileTmpl))
 )
 
 func init() {
+	opts.Rename = map[string]string{}
+
 	KP.Flag("indent", "Output indentation.").Default("\t").StringVar(&opts.Indent)
 	KP.Flag("mark-optional-fields", "Add `?` to fields with omitempty.").Short('m').BoolVar(&opts.MarkOptional)
+	KP.Flag("record-maps", "Use `Record<K, V>` for maps instead of index signatures.").BoolVar(&opts.RecordMaps)
+	KP.Flag("tag", "Struct tag to read the field names and options from (default json), can be repeated in priority order.").
+		PlaceHolder("json").StringsVar(&opts.TagNames)
+	KP.Flag("extend-embedded", "Extend embedded structs rather than flattening their fields.").BoolVar(&opts.ExtendEmbedded)
 	KP.Flag("es6", "generate es6 code").Short('6').BoolVar(&opts.ES6)
 	KP.Flag("no-ctor", "Don't generate a ctor.").Short('C').BoolVar(&opts.NoConstructor)
+	KP.Flag("no-factories", "Don't generate the static Class.fromJSON() and Class.fromArray() methods.").Short('F').BoolVar(&opts.NoFactories)
 	KP.Flag("no-toObject", "Don't generate a Class.toObject() method.").Short('T').BoolVar(&opts.NoToObject)
 	KP.Flag("no-exports", "Don't automatically export the generated types.").Short('E').BoolVar(&opts.NoExports)
 	KP.Flag("no-date", "Don't automatically handle time.Unix () <-> JS Date().").Short('D').BoolVar(&opts.NoDate)
+	KP.Flag("date-format", "Date wire format (auto, unix, unixms, unixnano or rfc3339).").
+		Default("auto").EnumVar((*string)(&opts.DateFormat), "auto", "unix", "unixms", "unixnano", "rfc3339")
+	KP.Flag("zero-date", "Default of missing dates, null and undefined also apply to Go's zero time (now, epoch, null or undefined).").
+		Default("now").EnumVar((*string)(&opts.ZeroDate), "now", "epoch", "null", "undefined")
 	KP.Flag("no-helpers", "Don't output the helpers.").Short('H').BoolVar(&opts.NoHelpers)
 	KP.Flag("no-default-values", "Don't assign default/zero values in the ctor.").Short('N').BoolVar(&opts.NoAssignDefaults)
 	KP.Flag("no-capitalize", "Don't capitalize TS class names.").Short('c').BoolVar(&opts.NoCapitalize)
+	KP.Flag("strict", "Fail if any of the structs has fields that can't be converted.").BoolVar(&opts.Strict)
+	KP.Flag("on-collision", "What to do when two types get the same name (error, package or hash).").
+		Default("error").EnumVar((*string)(&opts.OnNameCollision), "error", "package", "hash")
+	KP.Flag("rename", "Rename a type (github.com/you/billing.Config=BillingConfig), can be repeated.").StringMapVar(&opts.Rename)
+	KP.Flag("order", "Output order (added, topological, alpha or source).").
+		Default("added").EnumVar((*string)(&opts.Order), "added", "topological", "alpha", "source")
+	KP.Flag("readonly", "Make all fields readonly and add a Class.with(patch) method.").Short('r').BoolVar(&opts.Readonly)
+	KP.Flag("freeze", "Object.freeze() new instances.").BoolVar(&opts.Freeze)
+	KP.Flag("typed-init", "Type the ctor input as Partial<ClassInit> instead of any.").BoolVar(&opts.TypedInit)
+	KP.Flag("clone", "Generate a Class.clone() method.").BoolVar(&opts.Clone)
+	KP.Flag("equals", "Generate a Class.equals(other) method.").BoolVar(&opts.Equals)
 	KP.Flag("interface", "Only generate an interface (disables all the other options).").Short('i').BoolVar(&opts.InterfaceOnly)
 
 	KP.Flag("src-only", "Only output the Go code (helpful if you want to edit it yourself).").Short('s').BoolVar(&srcOnly)
@@ -52,6 +82,11 @@ func init() {
 
 	KP.Flag("keep-temp", "Keep the generated Go file, ignored if --src-only is set.").Short('k').BoolVar(&keepTemp)
 
+	KP.Flag("ir", "Output the type model as JSON instead of TS.").BoolVar(&writeIR)
+	KP.Flag("from-ir", "Render a type model written by --ir instead of Go types.").PlaceHolder("FILE").StringVar(&fromIR)
+
+	KP.Flag("template", "Render the output with a text/template file instead of the built-in renderer.").PlaceHolder("FILE").StringVar(&tmplFile)
+
 	KP.Flag("out", "Write the output to a file instead of stdout.").Short('o').Default("-").StringVar(&outFile)
 
 	KP.Arg("pkg.struct", "List of structs to convert (github.com/you/auth/users.User, users.User or users.User:AliasUser).").
@@ -67,6 +102,33 @@ func main() {
 	KP.Version(version).VersionFlag.Short('V')
 	KP.Parse()
 
+	if opts.OnNameCollision == "error" {
+		opts.OnNameCollision = struct2ts.CollisionError
+	}
+
+	if opts.Order == "added" {
+		opts.Order = struct2ts.OrderDefault
+	}
+
+	if opts.DateFormat == "auto" {
+		opts.DateFormat = struct2ts.DateDefault
+	}
+
+	if opts.ZeroDate == "now" {
+		opts.ZeroDate = struct2ts.ZeroDateNow
+	}
+
+	if tmplFile != "" {
+		b, err := ioutil.ReadFile(tmplFile)
+		if err != nil {
+			log.Panic(err)
+		}
+		if _, err = struct2ts.NewTemplate(tmplFile, string(b)); err != nil {
+			log.Panic(err)
+		}
+		tmplText = string(b)
+	}
+
 	out := os.Stdout
 
 	if outFile != "-" && outFile != "/dev/stdout" {
@@ -78,6 +140,13 @@ func main() {
 		out = of
 	}
 
+	if fromIR != "" {
+		if err := renderIR(out); err != nil {
+			log.Panic(err)
+		}
+		return
+	}
+
 	src, err := render()
 	if err != nil {
 		log.Panic(err)
@@ -149,6 +218,8 @@ func render() ([]byte, error) {
 		"pkgName":        pkgName,
 		"cmd":            strings.Join(os.Args[1:], " "),
 		"opts":           opts,
+		"ir":             writeIR,
+		"tmpl":           tmplText,
 		"imports":        imports,
 		"types":          ttypes,
 		"typesWithNames": typesWithNames,
@@ -157,6 +228,34 @@ func render() ([]byte, error) {
 	return buf.Bytes(), err
 }
 
+func renderIR(w io.Writer) error {
+	f, err := os.Open(fromIR)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ir, err := struct2ts.ReadIR(f)
+	if err != nil {
+		return err
+	}
+
+	s, err := struct2ts.FromIR(ir, &opts)
+	if err != nil {
+		return err
+	}
+
+	io.WriteString(w, "// this file was automatically generated, DO NOT EDIT\n")
+	if tmplText != "" {
+		tmpl, err := struct2ts.NewTemplate(tmplFile, tmplText)
+		if err != nil {
+			return err
+		}
+		return s.RenderTemplate(w, tmpl)
+	}
+	return s.RenderTo(w)
+}
+
 func tempFile() (f *os.File, err error) {
 	// if this somehow conflicts, god really hates us.
 	fpath := fmt.Sprintf("./s2ts_gen_%d_%d.go", time.Now().UnixNano(), rand.Int63())
@@ -210,12 +309,34 @@ func runStruct2TS(w io.Writer) error {
 		NoConstructor: {{ .opts.NoConstructor }},
 		NoCapitalize:  {{ .opts.NoCapitalize }},
 		MarkOptional:  {{ .opts.MarkOptional  }},
+		RecordMaps:    {{ .opts.RecordMaps    }},
+		NoFactories:   {{ .opts.NoFactories   }},
 		NoToObject:    {{ .opts.NoToObject    }},
 		NoExports:     {{ .opts.NoExports        }},
 		NoHelpers:     {{ .opts.NoHelpers        }},
 		NoDate:        {{ .opts.NoDate        }},
 
 		ES6:           {{ .opts.ES6 }},
+
+		Readonly:  {{ .opts.Readonly }},
+		Freeze:    {{ .opts.Freeze }},
+		TypedInit: {{ .opts.TypedInit }},
+		Clone:     {{ .opts.Clone }},
+		Equals:    {{ .opts.Equals }},
+
+		ExtendEmbedded: {{ .opts.ExtendEmbedded }},
+		Strict:         {{ .opts.Strict }},
+
+		DateFormat:      "{{ .opts.DateFormat }}",
+		ZeroDate:        "{{ .opts.ZeroDate }}",
+		Order:           "{{ .opts.Order }}",
+		OnNameCollision: "{{ .opts.OnNameCollision }}",
+		TagNames:        []string{ {{- range $_, $t := .opts.TagNames }}{{ printf "%q" $t }}, {{ end -}} },
+		Rename: map[string]string{
+			{{- range $k, $v := .opts.Rename }}
+			"{{ $k }}": "{{ $v }}",
+			{{- end }}
+		},
 	})
 
 	{{ range $_, $t := .types }}
@@ -225,7 +346,23 @@ func runStruct2TS(w io.Writer) error {
 	s.AddWithName({{index $t 0}}{}, "{{index $t 1}}")
 	{{- end }}
 
+	if err := s.Err(); err != nil && !{{ .opts.Strict }} {
+		log.Printf("skipped unsupported fields:\n%v", err)
+	}
+
+	{{- if .ir }}
+	return s.WriteIR(w)
+	{{- else if .tmpl }}
+	tmpl, err := struct2ts.NewTemplate("template", {{ printf "%q" .tmpl }})
+	if err != nil {
+		return err
+	}
+
+	io.WriteString(w, "// this file was automatically generated, DO NOT EDIT\n")
+	return s.RenderTemplate(w, tmpl)
+	{{- else }}
 	io.WriteString(w, "// this file was automatically generated, DO NOT EDIT\n")
 	return s.RenderTo(w)
+	{{- end }}
 }
 `
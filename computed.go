@@ -0,0 +1,386 @@
+package struct2ts
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Computed is a getter generated from a Go method marked with a `//ts:computed` comment,
+// or declared by a `ts:"computed"` pseudo-field (usually also tagged `json:"-"`) to be implemented by hand.
+type Computed struct {
+	Name string `json:"name"`
+
+	// Result describes the type the getter returns.
+	Result *Field `json:"result"`
+
+	// Expr is the TS translation of the method's return expression, it's empty if the method couldn't be translated
+	// and the getter is only declared, to be implemented by hand.
+	Expr string `json:"expr,omitempty"`
+}
+
+// computedTag parses `computed` and `computed:name`, name defaults to goName.
+func computedTag(tag, goName string) (name string, ok bool) {
+	switch {
+	case tag == "computed":
+		return goName, true
+	case strings.HasPrefix(tag, "computed:"):
+		return tag[len("computed:"):], true
+	default:
+		return "", false
+	}
+}
+
+// addComputed adds the getters of t's marked methods and computed pseudo-fields to out.
+func (s *StructToTS) addComputed(out *Struct, t reflect.Type) {
+	type getter struct {
+		name   string
+		result reflect.Type
+		decl   *ast.FuncDecl
+	}
+
+	if !hasComputed(t) {
+		return
+	}
+
+	var (
+		getters []getter
+		results = map[string]*Field{} // the results of the marked methods by Go name, see translator
+	)
+
+	// pseudo-fields come first, in field order, they can't have a method with the same name
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if name, ok := computedTag(strings.Split(sf.Tag.Get("ts"), ",")[0], sf.Name); ok {
+			getters = append(getters, getter{name: name, result: sf.Type})
+		}
+	}
+
+	// then the marked methods, in source order
+	for _, decl := range s.pkgs.get(t.PkgPath()).methods(genericName(t.Name())) {
+		goName := decl.Name.Name
+		if decl.Doc == nil {
+			continue
+		}
+
+		for _, c := range decl.Doc.List {
+			if !strings.HasPrefix(c.Text, "//ts:") {
+				continue
+			}
+
+			name, ok := computedTag(c.Text[len("//ts:"):], goName)
+			if !ok {
+				continue
+			}
+
+			if m, ok := reflect.PtrTo(t).MethodByName(goName); ok && m.Type.NumIn() == 1 && m.Type.NumOut() == 1 {
+				getters = append(getters, getter{name, m.Type.Out(0), decl})
+			}
+			break
+		}
+	}
+
+	decls := map[*Computed]*ast.FuncDecl{}
+	for _, g := range getters {
+		f, err := s.elemField(g.result, DateDefault, typePath(t)+"."+g.name)
+		if err != nil {
+			s.errs = append(s.errs, err)
+			continue
+		}
+
+		switch g.result.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map:
+			f.CanBeNull = true
+		}
+		f.Name = g.name
+
+		c := &Computed{Name: g.name, Result: f}
+		if g.decl != nil {
+			decls[c], results[g.decl.Name.Name] = g.decl, f
+		}
+		out.Computed = append(out.Computed, c)
+	}
+
+	// the getters can use each other, so they're translated once all their results are known
+	for _, c := range out.Computed {
+		if decl := decls[c]; decl != nil {
+			c.Expr = translateMethod(decl, out.goFields(), results)
+		}
+	}
+}
+
+// hasComputed reports whether t could have getters, so packages are only parsed when needed:
+// it has computed pseudo-fields or methods that could be marked with `//ts:computed`.
+func hasComputed(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := computedTag(strings.Split(t.Field(i).Tag.Get("ts"), ",")[0], ""); ok {
+			return true
+		}
+	}
+
+	pt := reflect.PtrTo(t)
+	for i := 0; i < pt.NumMethod(); i++ {
+		if m := pt.Method(i); m.Type.NumIn() == 1 && m.Type.NumOut() == 1 && !wellKnownMethods[m.Name] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wellKnownMethods are the methods of common interfaces that would otherwise make most types look like they have getters.
+var wellKnownMethods = map[string]bool{"String": true, "GoString": true, "Error": true}
+
+// translateMethod returns the TS translation of a method that only returns an expression,
+// or an empty string if it does anything else or uses anything translate doesn't support.
+func translateMethod(decl *ast.FuncDecl, fields map[string]*Field, getters map[string]*Field) string {
+	if decl.Body == nil || len(decl.Body.List) != 1 || len(decl.Recv.List[0].Names) != 1 {
+		return ""
+	}
+
+	ret, ok := decl.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return ""
+	}
+
+	tr := &translator{recv: decl.Recv.List[0].Names[0].Name, fields: fields, getters: getters}
+	out, ok := tr.expr(ret.Results[0])
+	if !ok {
+		return ""
+	}
+	return out
+}
+
+// translator translates trivial Go expressions to TS: the receiver's fields and getters,
+// literals, arithmetic (except for `/`, integer division doesn't exist in JS), comparisons, boolean operators,
+// `len` of arrays and maps and a few functions of the strings package.
+// Operators are only translated for numbers, strings and bools: `===` doesn't compare anything else like Go does
+// and dates or fields with an overridden type don't support arithmetic.
+type translator struct {
+	recv    string
+	fields  map[string]*Field // by Go name
+	getters map[string]*Field // the results of the getters by Go name
+}
+
+var stringFuncs = map[string]string{
+	"ToUpper":   "toUpperCase",
+	"ToLower":   "toLowerCase",
+	"TrimSpace": "trim",
+}
+
+func (tr *translator) expr(e ast.Expr) (string, bool) {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.INT, token.FLOAT:
+			return e.Value, true
+		case token.STRING:
+			v, err := strconv.Unquote(e.Value)
+			return jsString(v), err == nil
+		}
+
+	case *ast.Ident:
+		switch e.Name {
+		case "true", "false":
+			return e.Name, true
+		case "nil":
+			return "null", true
+		}
+
+	case *ast.SelectorExpr:
+		if f := tr.field(e); f != nil {
+			return "this." + f.Name, true
+		}
+
+	case *ast.ParenExpr:
+		x, ok := tr.expr(e.X)
+		return "(" + x + ")", ok
+
+	case *ast.UnaryExpr:
+		if k := tr.kind(e.X); e.Op == token.NOT && k == "boolean" || e.Op == token.SUB && k == "number" {
+			x, ok := tr.expr(e.X)
+			return e.Op.String() + x, ok
+		}
+
+	case *ast.BinaryExpr:
+		op, xk, yk := e.Op.String(), tr.kind(e.X), tr.kind(e.Y)
+		switch e.Op {
+		case token.ADD: // numbers or strings
+			if xk != yk || xk != "number" && xk != "string" {
+				return "", false
+			}
+		case token.SUB, token.MUL, token.REM:
+			if xk != "number" || yk != "number" {
+				return "", false
+			}
+		case token.LSS, token.GTR, token.LEQ, token.GEQ:
+			if xk != yk || xk != "number" && xk != "string" {
+				return "", false
+			}
+		case token.LAND, token.LOR:
+			if xk != "boolean" || yk != "boolean" {
+				return "", false
+			}
+		case token.EQL, token.NEQ:
+			if xk == "" || yk == "" {
+				return "", false
+			}
+			op += "="
+		default:
+			return "", false
+		}
+
+		x, xok := tr.expr(e.X)
+		y, yok := tr.expr(e.Y)
+		return x + " " + op + " " + y, xok && yok
+
+	case *ast.CallExpr:
+		return tr.call(e)
+	}
+
+	return "", false
+}
+
+func (tr *translator) call(e *ast.CallExpr) (string, bool) {
+	switch fn := e.Fun.(type) {
+	case *ast.Ident:
+		if fn.Name != "len" || len(e.Args) != 1 {
+			return "", false
+		}
+
+		// JS counts the UTF-16 code units of strings rather than their bytes
+		x, ok := tr.operand(e.Args[0])
+		f := tr.field(e.Args[0])
+		switch {
+		case f == nil || f.Elem == nil:
+			return "", false
+		case f.TsType == "map":
+			return "Object.keys(" + x + " || {}).length", ok
+		case f.CanBeNull:
+			return "(" + x + " || []).length", ok
+		default:
+			return x + ".length", ok
+		}
+
+	case *ast.SelectorExpr:
+		x, ok := fn.X.(*ast.Ident)
+		if !ok {
+			return "", false
+		}
+
+		switch {
+		case x.Name == tr.recv && len(e.Args) == 0 && tr.getters[fn.Sel.Name] != nil:
+			return "this." + tr.getters[fn.Sel.Name].Name, true
+		case x.Name == "strings" && len(e.Args) == 1 && stringFuncs[fn.Sel.Name] != "" && tr.kind(e.Args[0]) == "string":
+			arg, ok := tr.operand(e.Args[0])
+			return arg + "." + stringFuncs[fn.Sel.Name] + "()", ok
+		}
+	}
+
+	return "", false
+}
+
+// operand translates e so it can be followed by a property access.
+func (tr *translator) operand(e ast.Expr) (string, bool) {
+	x, ok := tr.expr(e)
+	switch e.(type) {
+	case *ast.BinaryExpr, *ast.UnaryExpr:
+		x = "(" + x + ")"
+	}
+	return x, ok
+}
+
+// kind returns the TS type of e if it's a number, a string or a bool, or an empty string otherwise,
+// operators and functions are only translated for these, which they treat like Go does.
+func (tr *translator) kind(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.INT, token.FLOAT:
+			return "number"
+		case token.STRING:
+			return "string"
+		}
+	case *ast.Ident:
+		if e.Name == "true" || e.Name == "false" {
+			return "boolean"
+		}
+	case *ast.ParenExpr:
+		return tr.kind(e.X)
+	case *ast.UnaryExpr: // only translated for bools and numbers
+		if e.Op == token.NOT {
+			return "boolean"
+		}
+		return "number"
+	case *ast.BinaryExpr: // only translated if the operands match
+		switch e.Op {
+		case token.ADD:
+			return tr.kind(e.X)
+		case token.SUB, token.MUL, token.REM:
+			return "number"
+		}
+		return "boolean"
+	case *ast.SelectorExpr:
+		return basicType(tr.field(e))
+	case *ast.CallExpr:
+		if fn, ok := e.Fun.(*ast.SelectorExpr); ok {
+			if x, ok := fn.X.(*ast.Ident); ok && x.Name == tr.recv {
+				return basicType(tr.getters[fn.Sel.Name])
+			}
+			return "string" // the strings funcs
+		}
+		return "number" // len
+	}
+	return ""
+}
+
+// basicType returns the TS type of f if it's a non-nullable number, string or bool, dates are Date objects.
+func basicType(f *Field) string {
+	if f == nil || f.isPtr || f.IsDate || f.IsRaw || f.Override != nil && f.Override.Type != "" {
+		return ""
+	}
+
+	switch f.TsType {
+	case "number", "string", "boolean":
+		return f.TsType
+	default:
+		return ""
+	}
+}
+
+// field returns the field of the receiver e selects, or nil.
+func (tr *translator) field(e ast.Expr) *Field {
+	if sel, ok := e.(*ast.SelectorExpr); ok {
+		if x, ok := sel.X.(*ast.Ident); ok && x.Name == tr.recv {
+			return tr.fields[sel.Sel.Name]
+		}
+	}
+	return nil
+}
+
+// goFields returns s's fields, including the ones inherited from its embedded structs, by Go name.
+func (s *Struct) goFields() map[string]*Field {
+	out := map[string]*Field{}
+	for _, e := range s.Embeds {
+		for n, f := range e.goFields() {
+			out[n] = f
+		}
+	}
+
+	for _, f := range s.Fields {
+		if f.goName != "" {
+			out[f.goName] = f
+		}
+	}
+
+	return out
+}
+
+// jsString returns s as a single quoted JS string.
+func jsString(s string) string {
+	q := strconv.Quote(s)
+	q = strings.Replace(q[1:len(q)-1], `\"`, `"`, -1)
+	return "'" + strings.Replace(q, "'", `\'`, -1) + "'"
+}
@@ -0,0 +1,127 @@
+package struct2ts
+
+import "fmt"
+
+// DateFormat is the wire format of a date field, see Options.DateFormat.
+type DateFormat string
+
+const (
+	// DateDefault encodes time.Time as an RFC 3339 string and everything else as Unix seconds,
+	// numbers are parsed as seconds or milliseconds depending on their size.
+	DateDefault DateFormat = ""
+	// DateUnix is the number of seconds since the Unix epoch.
+	DateUnix DateFormat = "unix"
+	// DateUnixMs is the number of milliseconds since the Unix epoch.
+	DateUnixMs DateFormat = "unixms"
+	// DateUnixNano is the number of nanoseconds since the Unix epoch,
+	// JS numbers can't hold that precisely so the sub-millisecond part is lost.
+	DateUnixNano DateFormat = "unixnano"
+	// DateRFC3339 is an RFC 3339 string, the format encoding/json uses for time.Time.
+	DateRFC3339 DateFormat = "rfc3339"
+)
+
+// ZeroDate is how missing dates and Go's zero time (`0001-01-01T00:00:00Z`) are represented, see Options.ZeroDate.
+type ZeroDate string
+
+const (
+	// ZeroDateNow defaults missing dates to `new Date()`, Go's zero time is parsed as is (year 1).
+	ZeroDateNow ZeroDate = ""
+	// ZeroDateEpoch defaults missing dates to `new Date(0)`, Go's zero time is parsed as is (year 1).
+	ZeroDateEpoch ZeroDate = "epoch"
+	// ZeroDateNull makes all the dates nullable, missing dates and Go's zero time are parsed as null
+	// and toObject turns null back into Go's zero time.
+	ZeroDateNull ZeroDate = "null"
+	// ZeroDateUndefined is the same as ZeroDateNull but uses undefined.
+	ZeroDateUndefined ZeroDate = "undefined"
+)
+
+// InvalidDateFormatError is returned for fields tagged with an unknown date format,
+// or with a Unix format on a time.Time, which encoding/json always encodes as an RFC 3339 string.
+type InvalidDateFormatError struct {
+	Path   string
+	Format DateFormat
+}
+
+func (e *InvalidDateFormatError) Error() string {
+	return fmt.Sprintf("%s: invalid date format %q", e.Path, e.Format)
+}
+
+func (df DateFormat) valid() bool {
+	switch df {
+	case DateDefault, DateUnix, DateUnixMs, DateUnixNano, DateRFC3339:
+		return true
+	default:
+		return false
+	}
+}
+
+// validDateFormat reports whether the date formats of f and its elements are known and fit their Go types.
+func (f *Field) validDateFormat() bool {
+	for ; f != nil; f = f.Elem {
+		if !f.DateFormat.valid() || f.IsDate && f.TsType != "number" && !f.DateFormat.isString() {
+			return false
+		}
+	}
+	return true
+}
+
+func (df DateFormat) isString() bool { return df == DateDefault || df == DateRFC3339 }
+
+// dateFormat returns the format used for f, or DateDefault if f isn't a date.
+// Options.DateFormat only applies to numeric dates if it's a Unix format, time.Time is always RFC 3339.
+func (f *Field) dateFormat(opts *Options) DateFormat {
+	switch {
+	case !f.IsDate || opts.NoDate:
+		return DateDefault
+	case f.DateFormat != DateDefault:
+		return f.DateFormat
+	case f.TsType != "number" && !opts.DateFormat.isString():
+		return DateDefault
+	default:
+		return opts.DateFormat
+	}
+}
+
+// parseDate returns a ParseDate call for v.
+func (f *Field) parseDate(opts *Options, v string) string {
+	df := f.dateFormat(opts)
+	switch f.zeroDate {
+	case ZeroDateNull, ZeroDateUndefined:
+		return fmt.Sprintf("ParseNullDate(%s, '%s', %s)", v, df, f.zeroDate)
+	}
+
+	if df != DateDefault {
+		return fmt.Sprintf("ParseDate(%s, '%s')", v, df)
+	}
+	return "ParseDate(" + v + ")"
+}
+
+// zeroValue reports whether ToObject should turn a null date back into Go's zero time,
+// which is the case unless Go itself would output null or omit the field.
+func (f *Field) zeroValue(goTime bool) bool {
+	switch {
+	case f.zeroDate != ZeroDateNull && f.zeroDate != ZeroDateUndefined, f.isPtr:
+		return false
+	case goTime:
+		return !f.omitZero // encoding/json never omits structs with omitempty
+	default:
+		return !f.omitZero && !f.omitEmpty
+	}
+}
+
+// dateType returns the ToObject type of a date, or an empty string if ToObject's default (Unix seconds) works.
+func (f *Field) dateType(opts *Options, goTime bool) string {
+	switch f.dateFormat(opts) {
+	case DateRFC3339:
+		return "string"
+	case DateDefault:
+		if goTime {
+			return "string"
+		}
+		return ""
+	case DateUnix:
+		return ""
+	default:
+		return string(f.dateFormat(opts))
+	}
+}
@@ -0,0 +1,228 @@
+package struct2ts
+
+import (
+	"go/ast"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// jsonField is a struct field as encoding/json sees it, Index is the full path from the top level struct.
+type jsonField struct {
+	reflect.StructField
+
+	name   string
+	tagged bool
+	viaPtr bool // promoted through an embedded pointer, encoding/json omits it if the pointer is nil
+}
+
+// jsonFields returns the fields of t following the same rules encoding/json uses, with the tags read from tagNames:
+// untagged embedded structs and structs tagged `,inline` (but not in a json tag) are flattened, tagged ones are treated as a regular field
+// and conflicting names are resolved by depth and then by tags, dropping the field if that's still ambiguous.
+func jsonFields(t reflect.Type, tagNames []string) []jsonField {
+	type embedded struct {
+		t     reflect.Type
+		index []int
+		ptr   bool
+	}
+
+	var (
+		fields  []jsonField
+		next    = []embedded{{t: t}}
+		visited = map[reflect.Type]bool{}
+	)
+
+	for len(next) > 0 {
+		current := next
+		next = nil
+
+		level := map[reflect.Type]bool{}
+		for _, e := range current {
+			if visited[e.t] {
+				continue
+			}
+			level[e.t] = true
+
+			for i := 0; i < e.t.NumField(); i++ {
+				sf := e.t.Field(i)
+				ft := indirect(sf.Type)
+
+				if sf.Anonymous {
+					if !ast.IsExported(sf.Name) && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !ast.IsExported(sf.Name) {
+					continue
+				}
+
+				tag, _, _ := fieldTag(sf, tagNames)
+				if tag == "-" || strings.Split(sf.Tag.Get("ts"), ",")[0] == "-" {
+					continue
+				}
+
+				sf.Index = append(append(make([]int, 0, len(e.index)+1), e.index...), i)
+
+				if !isInlined(sf, tagNames) {
+					name := tag
+					if name == "" {
+						name = defaultName(sf.Name, tagNames)
+					}
+					fields = append(fields, jsonField{StructField: sf, name: name, tagged: tag != "", viaPtr: e.ptr})
+					continue
+				}
+
+				next = append(next, embedded{ft, sf.Index, e.ptr || sf.Type.Kind() == reflect.Ptr})
+			}
+		}
+
+		for t := range level {
+			visited[t] = true
+		}
+	}
+
+	return dominantFields(fields)
+}
+
+// dominantFields drops the fields hidden by Go's embedding rules and sorts the rest in declaration order.
+func dominantFields(fields []jsonField) []jsonField {
+	byName := map[string][]jsonField{}
+	for _, f := range fields {
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	out := fields[:0]
+	for _, f := range fields {
+		if dominant, ok := dominantField(byName[f.name]); ok && sameIndex(dominant.Index, f.Index) {
+			out = append(out, f)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].Index, out[j].Index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	return out
+}
+
+func dominantField(fields []jsonField) (jsonField, bool) {
+	depth := len(fields[0].Index)
+	for _, f := range fields[1:] {
+		if len(f.Index) < depth {
+			depth = len(f.Index)
+		}
+	}
+
+	var shallow, tagged []jsonField
+	for _, f := range fields {
+		if len(f.Index) != depth {
+			continue
+		}
+		if shallow = append(shallow, f); f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+
+	switch {
+	case len(shallow) == 1:
+		return shallow[0], true
+	case len(tagged) == 1:
+		return tagged[0], true
+	default:
+		return jsonField{}, false
+	}
+}
+
+func sameIndex(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// promotesAll reports whether all the fields of the embedded struct t.Field(i) are promoted to t,
+// which isn't the case if some of them are shadowed by t's own fields or conflict with another embedded struct's.
+func promotesAll(t reflect.Type, i int, fields []jsonField, tagNames []string) bool {
+	n := 0
+	for _, f := range fields {
+		if f.Index[0] == i {
+			n++
+		}
+	}
+	return n == len(jsonFields(indirect(t.Field(i).Type), tagNames))
+}
+
+// fieldTag returns the name and options of the first tag in tagNames sf has and which tag that is,
+// ts is skipped since it only holds struct2ts's own options.
+func fieldTag(sf reflect.StructField, tagNames []string) (name string, opts []string, tag string) {
+	for _, n := range tagNames {
+		if n == "ts" {
+			continue
+		}
+
+		if v, ok := sf.Tag.Lookup(n); ok {
+			parts := strings.Split(v, ",")
+			return parts[0], parts[1:], n
+		}
+	}
+
+	return "", nil, ""
+}
+
+// defaultNames are the names codecs give the fields their tags don't name, encoding/json and most others keep the Go name.
+var defaultNames = map[string]func(string) string{
+	"bson": strings.ToLower,
+	"yaml": strings.ToLower,
+}
+
+// defaultName returns the name of a field its tag doesn't name,
+// following the first codec in tagNames since that's the one the output is for.
+func defaultName(goName string, tagNames []string) string {
+	for _, n := range tagNames {
+		if n == "ts" {
+			continue
+		}
+		if fn := defaultNames[n]; fn != nil {
+			return fn(goName)
+		}
+		break
+	}
+	return goName
+}
+
+// isInlined reports whether sf's fields are flattened into its parent, either because it's an untagged embedded struct
+// or because it's a struct tagged `,inline`, which encoding/json doesn't support so it's ignored in json tags.
+func isInlined(sf reflect.StructField, tagNames []string) bool {
+	name, opts, tag := fieldTag(sf, tagNames)
+	if name == "" && isEmbeddedStruct(sf) {
+		return true
+	}
+
+	if t := indirect(sf.Type); t.Kind() != reflect.Struct || isDate(t) || tag == "json" {
+		return false
+	}
+
+	for _, opt := range opts {
+		if opt == "inline" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isEmbeddedStruct reports whether sf is an embedded struct (or a pointer to one) that should be flattened.
+func isEmbeddedStruct(sf reflect.StructField) bool {
+	t := indirect(sf.Type)
+	return sf.Anonymous && t.Kind() == reflect.Struct && !isDate(t)
+}
@@ -0,0 +1,74 @@
+package struct2ts
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// UnsupportedTypeError is returned for fields that encoding/json can't handle, like channels, funcs or complex numbers.
+type UnsupportedTypeError struct {
+	// Path is the Go path of the field, for example `github.com/you/users.User.Callback`,
+	// `[]` marks array elements and `[key]` map values.
+	Path string
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return fmt.Sprintf("%s: unsupported type %s", e.Path, e.Type)
+}
+
+// UnsupportedOptionError is returned for fields with a json tag option the generated code can't follow,
+// like `string` on a string or a date, the field is still generated but keeps its wire value.
+type UnsupportedOptionError struct {
+	Path   string
+	Option string
+}
+
+func (e *UnsupportedOptionError) Error() string {
+	return fmt.Sprintf("%s: unsupported json option %q", e.Path, e.Option)
+}
+
+// NameCollisionError is returned when two different Go types get the same TS name, see Options.OnNameCollision.
+type NameCollisionError struct {
+	Name          string
+	First, Second string // the full Go paths of both types
+}
+
+func (e *NameCollisionError) Error() string {
+	return fmt.Sprintf("%s and %s are both named %s", e.First, e.Second, e.Name)
+}
+
+// Errors is a list of all the errors found while adding types.
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// errWriter remembers the first write error and skips all the writes after it,
+// so the render functions only have to check for errors once.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func newErrWriter(w io.Writer) *errWriter {
+	if ew, ok := w.(*errWriter); ok {
+		return ew
+	}
+	return &errWriter{w: w}
+}
+
+func (ew *errWriter) Write(p []byte) (n int, err error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	n, ew.err = ew.w.Write(p)
+	return n, ew.err
+}
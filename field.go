@@ -1,31 +1,102 @@
 package struct2ts
 
 import (
+	"encoding/json"
 	"fmt"
 	"go/ast"
 	"io"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
 type Field struct {
-	Name       string `json:"name"`
-	TsType     string `json:"type"`
-	KeyType    string `json:"keyType,omitempty"`
-	ValType    string `json:"valType,omitempty"`
-	CanBeNull  bool   `json:"canBeNull"`
-	IsOptional bool   `json:"isOptional"`
-	IsDate     bool   `json:"isDate"`
-	IsRaw      bool   `json:"isRaw"`
+	Name       string     `json:"name"`
+	TsType     string     `json:"type"`
+	KeyType    string     `json:"keyType,omitempty"`
+	ValType    string     `json:"valType,omitempty"`
+	Len        int        `json:"len,omitempty"`
+	CanBeNull  bool       `json:"canBeNull"`
+	IsOptional bool       `json:"isOptional"`
+	IsDate     bool       `json:"isDate"`
+	DateFormat DateFormat `json:"dateFormat,omitempty"`
+	IsRaw      bool       `json:"isRaw"`
+	IsReadonly bool       `json:"isReadonly"`
+
+	// Elem describes the element type of arrays, tuples and maps, it's nil for all other types.
+	Elem *Field `json:"elem,omitempty"`
+
+	// Override replaces parts of the generated code, see StructToTS.OverrideField.
+	Override *FieldOverride `json:"override,omitempty"`
+
+	isPtr  bool
+	goName string // the name of the Go struct field
+	viaPtr bool   // promoted through an embedded pointer, it's undefined if the pointer was nil
+
+	// omitEmpty and omitZero are the encoding/json tag options
+	omitEmpty, omitZero bool
+
+	// quoted is the encoding/json string option, the number or bool is encoded as a string (`"5"`, `"true"`)
+	quoted bool
+
+	// goKind is the kind of the Go field, it's only set for struct fields
+	goKind reflect.Kind
+
+	zeroDate ZeroDate
+}
+
+// FieldOverride replaces parts of the generated code of a field, empty values keep the generated code.
+type FieldOverride struct {
+	// Type is the TS type of the field (`Decimal`), the Go type isn't converted if it's set.
+	Type string `json:"type,omitempty"`
+	// Ctor is the expression the ctor assigns to the field, the input data is `d`: `new Decimal(d.price || 0)`.
+	Ctor string `json:"ctor,omitempty"`
+	// Default is the default value of the field, the ctor uses it if the field is missing from the input data.
+	Default string `json:"default,omitempty"`
+	// ToObject is an expression that converts the value of the field `v` for toObject: `v.toString()`,
+	// the field is omitted if it returns undefined.
+	ToObject string `json:"toObject,omitempty"`
+}
+
+// FieldHook returns the override of a field, or nil to keep its generated code.
+type FieldHook func(f *Field) *FieldOverride
+
+// CustomTypescriptField can be implemented by the type of a field to override its generated code,
+// the method is called on a zero value, like CustomTypescript.
+type CustomTypescriptField interface {
+	TypescriptField(f *Field) *FieldOverride
 }
 
 func (f *Field) Type(opts *Options, noSuffix bool) (out string) {
+	readonly := f.IsReadonly || opts.Readonly
+
 	switch out = f.TsType; out {
 	case "string", "number", "boolean":
 	case "array":
-		out = f.ValType + "[]"
+		if readonly {
+			out = "ReadonlyArray<" + f.elemType(opts) + ">"
+			break
+		}
+		if out = f.elemType(opts); isUnion(out) {
+			out = "(" + out + ")"
+		}
+		out += "[]"
+	case "tuple":
+		if out = "[" + f.repeat(f.elemType(opts)) + "]"; readonly {
+			out = "readonly " + out
+		}
 	case "map":
-		out = fmt.Sprintf("{ [key: %s]: %s }", f.KeyType, f.ValType)
+		switch {
+		case f.KeyType != "string": // enum keys, Go maps don't have to have all of them
+			out = fmt.Sprintf("Partial<Record<%s, %s>>", f.KeyType, f.elemType(opts))
+		case opts.RecordMaps:
+			out = fmt.Sprintf("Record<%s, %s>", f.KeyType, f.elemType(opts))
+		default:
+			out = fmt.Sprintf("{ [key: %s]: %s }", f.KeyType, f.elemType(opts))
+		}
+		if readonly {
+			out = "Readonly<" + out + ">"
+		}
 	case "object":
 		if out = f.ValType; out == "" {
 			out = "any"
@@ -40,81 +111,399 @@ func (f *Field) Type(opts *Options, noSuffix bool) (out string) {
 		out = "any"
 	}
 
-	if !noSuffix && f.CanBeNull {
+	if !noSuffix && f.CanBeNull && out != "any" { // any includes null
 		out += " | null"
 	}
 
+	if !noSuffix && f.canBeUndefined() {
+		out += " | undefined"
+	}
+
 	return
 }
 
-func (f *Field) RenderTopLevel(w io.Writer, opts *Options) (err error) {
-	name, t := f.Name, f.Type(opts, false)
-	if f.IsOptional && opts.MarkOptional {
-		name += "?"
+// canBeUndefined reports whether f is undefined when it's missing from the input data.
+func (f *Field) canBeUndefined() bool {
+	return f.zeroDate == ZeroDateUndefined || f.viaPtr
+}
+
+// InitType returns the TS type f accepts as constructor input, see Options.TypedInit.
+// Dates also accept anything ParseDate does and structs use their Init type.
+func (f *Field) InitType(opts *Options) (out string) {
+	switch {
+	case f.IsRaw:
+		return "any"
+	case f.IsDate && !opts.NoDate:
+		out = "Date | number | string"
+	case f.TsType == "object" && f.ValType != "":
+		out = "Partial<" + f.ValType + "Init>"
+	case f.TsType == "array":
+		if out = f.Elem.InitType(opts); isUnion(out) {
+			out = "(" + out + ")"
+		}
+		out += "[]"
+	case f.TsType == "tuple":
+		out = "[" + f.repeat(f.Elem.InitType(opts)) + "]"
+	case f.TsType == "map" && f.KeyType != "string":
+		out = fmt.Sprintf("Partial<Record<%s, %s>>", f.KeyType, f.Elem.InitType(opts))
+	case f.TsType == "map":
+		out = fmt.Sprintf("{ [key: %s]: %s }", f.KeyType, f.Elem.InitType(opts))
+	default:
+		return f.Type(opts, false)
 	}
 
-	io.WriteString(w, opts.indents[1])
-	io.WriteString(w, name+": ")
+	if f.CanBeNull {
+		out += " | null"
+	}
+
+	return
+}
 
-	if opts.InterfaceOnly || opts.NoAssignDefaults || !opts.NoConstructor {
-		_, err = io.WriteString(w, t)
-	} else {
-		_, err = fmt.Fprintf(w, "%s = %s", t, f.DefaultValue())
+// isUnion reports whether t is a union type at the top level, `(A | null)[]` isn't one.
+func isUnion(t string) bool {
+	depth := 0
+	for i := 0; i < len(t); i++ {
+		switch t[i] {
+		case '(', '[', '{', '<':
+			depth++
+		case ')', ']', '}', '>':
+			if t[i] == '>' && i > 0 && t[i-1] == '=' { // arrow function types
+				continue
+			}
+			depth--
+		case '|':
+			if depth == 0 {
+				return true
+			}
+		}
 	}
+	return false
+}
 
-	io.WriteString(w, ";\n")
+func (f *Field) elemType(opts *Options) string {
+	if f.Elem == nil {
+		return f.ValType
+	}
+	return f.Elem.Type(opts, false)
+}
 
-	return
+// RenderTopLevel renders the TS declaration of f.
+func (f *Field) RenderTopLevel(w io.Writer, opts *Options) (err error) {
+	return renderDecl(w, opts, f, !opts.InterfaceOnly && !opts.NoAssignDefaults && opts.NoConstructor)
+}
+
+// declName returns the name f is declared with, including the readonly and optional markers.
+func (f *Field) declName(opts *Options) string {
+	name := f.Name
+	if f.IsOptional && opts.MarkOptional || f.viaPtr {
+		name += "?"
+	}
+
+	if f.IsReadonly || opts.Readonly {
+		name = "readonly " + name
+	}
+
+	return name
 }
 
 func (f *Field) RenderCtor(w io.Writer, opts *Options) (err error) {
+	ew := newErrWriter(w)
+	w = ew
+
+	io.WriteString(w, opts.indents[2])
+	io.WriteString(w, "this.")
+	io.WriteString(w, f.Name)
+	io.WriteString(w, " = ")
+	io.WriteString(w, f.ctorExpr(opts))
+	io.WriteString(w, ";\n")
+	return ew.err
+}
+
+// ctorExpr returns the expression the ctor assigns to f, from the input data `d`.
+func (f *Field) ctorExpr(opts *Options) string {
+	if f.Override != nil && f.Override.Ctor != "" {
+		return f.Override.Ctor
+	}
+
 	var (
 		t            = f.Type(opts, true)
 		d            = f.DefaultValue()
 		printDefault = true
+		mapper       string
+		out          string
 	)
 
-	io.WriteString(w, opts.indents[2])
-	io.WriteString(w, "this.")
-	io.WriteString(w, f.Name)
-	io.WriteString(w, " = ")
+	if f.Elem != nil {
+		mapper = f.Elem.mapper(opts, 0)
+	}
 
 	switch {
 	case t == "Date":
 		// convert to js date
-		_, err = fmt.Fprintf(w, "('%s' in d) ? ParseDate(d.%s)", f.Name, f.Name)
+		out = fmt.Sprintf("('%s' in d) ? %s", f.Name, f.convert(opts, "d."+f.Name, 0))
+	case f.quoted:
+		out = fmt.Sprintf("('%s' in d) ? %s", f.Name, f.convert(opts, "d."+f.Name, 0))
 	case t == f.ValType: // struct
-		if printDefault = d == "null"; printDefault {
-			_, err = fmt.Fprintf(w, "('%s' in d) ? new %s(d.%s)", f.Name, f.ValType, f.Name)
+		if printDefault = d == "null" || d == "undefined"; printDefault { // encoding/json encodes nil pointers as null
+			out = fmt.Sprintf("(d.%s != null) ? new %s(d.%s)", f.Name, f.ValType, f.Name)
 		} else {
-			_, err = fmt.Fprintf(w, "new %s(d.%s)", f.ValType, f.Name)
-		}
-	case f.TsType == "array" && !f.IsNative():
-		_, err = fmt.Fprintf(w, "Array.isArray(d.%s) ? d.%s.map((v%s) => new %s(v))",
-			f.Name, f.Name, TypeSuffix("any", opts.ES6, false), f.ValType)
-	case f.TsType == "map" && !f.IsNative():
-		// fmt.Fprintf(w, "Object.keys(d.%s || {}).mp((k: any) => new %s(v));\n",
-		//  f.Name, f.ValType)
-		fallthrough
+			out = fmt.Sprintf("new %s(d.%s)", f.ValType, f.Name)
+		}
+	case f.TsType == "array" && mapper != "":
+		out = fmt.Sprintf("Array.isArray(d.%s) ? d.%s%s", f.Name, f.Name, mapper)
+	case f.TsType == "tuple":
+		out = fmt.Sprintf("(Array.isArray(d.%s) && d.%s.length === %d) ? d.%s%s%s",
+			f.Name, f.Name, f.Len, f.Name, mapper, TypeSuffix(t, opts.ES6, true))
+	case f.TsType == "map" && mapper != "":
+		printDefault = false
+		out = f.convert(opts, "d."+f.Name, 0)
 	default:
-		_, err = fmt.Fprintf(w, "('%s' in d) ? d.%s%s", f.Name, f.Name, TypeSuffix(t, opts.ES6, true))
+		out = fmt.Sprintf("('%s' in d) ? d.%s%s", f.Name, f.Name, TypeSuffix(t, opts.ES6, true))
 	}
 
 	if printDefault {
-		io.WriteString(w, " : ")
-		io.WriteString(w, f.DefaultValue())
+		out += " : " + d
+	}
+
+	return out
+}
+
+// mapper returns a `.map(...)` call that converts every element of an array to f's type,
+// or an empty string if the elements can be used as is.
+func (f *Field) mapper(opts *Options, depth int) string {
+	if fn := f.converter(opts, depth); fn != "" {
+		return ".map(" + fn + ")"
+	}
+	return ""
+}
+
+// converter returns an arrow function that converts a raw value to f's type,
+// or an empty string if the value can be used as is.
+func (f *Field) converter(opts *Options, depth int) string {
+	v := varName("v", depth)
+
+	conv := f.convert(opts, v, depth+1)
+	if conv == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("(%s%s) => %s", v, TypeSuffix("any", opts.ES6, false), conv)
+}
+
+// convert returns an expression that converts the raw value v to f's type,
+// or an empty string if v can be used as is.
+func (f *Field) convert(opts *Options, v string, depth int) string {
+	switch {
+	case f.IsRaw:
+	case f.quoted:
+		conv := "ParseNumber(" + v + ")"
+		if f.TsType == "boolean" {
+			conv = fmt.Sprintf("(%s === true || %s === 'true')", v, v)
+		}
+		if f.isPtr {
+			return fmt.Sprintf("(%s == null ? null : %s)", v, conv)
+		}
+		return conv
+	case f.IsDate && !opts.NoDate:
+		if f.isPtr && f.zeroDate != ZeroDateNull && f.zeroDate != ZeroDateUndefined { // ParseNullDate handles null
+			return fmt.Sprintf("(%s == null ? null : %s)", v, f.parseDate(opts, v))
+		}
+		return f.parseDate(opts, v)
+	case f.TsType == "object" && f.ValType != "":
+		if f.isPtr {
+			return fmt.Sprintf("(%s == null ? null : new %s(%s))", v, f.ValType, v)
+		}
+		return "new " + f.ValType + "(" + v + ")"
+	case f.TsType == "array":
+		if m := f.Elem.mapper(opts, depth); m != "" {
+			return fmt.Sprintf("(Array.isArray(%s) ? %s%s : %s)", v, v, m, f.DefaultValue())
+		}
+	case f.TsType == "map":
+		if fn := f.Elem.converter(opts, depth); fn != "" {
+			return fmt.Sprintf("ParseMap(%s, %s, %s)%s", v, fn, f.DefaultValue(), TypeSuffix(f.Type(opts, true), opts.ES6, true))
+		}
+	case f.TsType == "tuple":
+		if m := f.Elem.mapper(opts, depth); m != "" {
+			return fmt.Sprintf("((Array.isArray(%s) && %s.length === %d) ? %s%s%s : %s)",
+				v, v, f.Len, v, m, TypeSuffix(f.Type(opts, true), opts.ES6, true), f.DefaultValue())
+		}
+	}
+	return ""
+}
+
+// varName returns the name of the arrow function parameter used at depth.
+func varName(v string, depth int) string {
+	if depth > 0 {
+		v += strconv.Itoa(depth)
+	}
+	return v
+}
+
+// toObjectCfg returns the ToObject cfg of f: the type its value (or its elements) are serialized as,
+// `string` for numbers and bools with the json string option, followed by a flag telling ToObject how encoding/json handles f's empty values:
+//
+//	zero      null dates are serialized as Go's zero time, see Options.ZeroDate.
+//	null      null is serialized as is rather than omitted.
+//	omitempty false, 0, '', empty arrays and maps are omitted.
+//	omitzero  false, 0, '' and Go's zero time are omitted.
+//
+// Null values are omitted otherwise. The `optional` flag is added to fields promoted through an embedded pointer,
+// they're omitted if they're undefined.
+func (f *Field) toObjectCfg(opts *Options) string {
+	// arrays and maps pass the type down to their elements
+	leaf := f
+	for leaf.Elem != nil {
+		leaf = leaf.Elem
+	}
+
+	cfg := []string{""}
+	switch leaf.Type(opts, true) {
+	case "Date":
+		cfg[0] = leaf.dateType(opts, leaf.TsType != "number")
+	case "number":
+		if cfg[0] = "number"; f.quoted {
+			cfg[0] = "string"
+		}
+	case "boolean":
+		if f.quoted {
+			cfg[0] = "string"
+		}
+	}
+
+	var nullable, basic bool
+	switch f.goKind {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		nullable = true
+	case reflect.Struct, reflect.Array:
+	default:
+		basic = true
+	}
+
+	switch {
+	case leaf == f && f.zeroValue(f.TsType != "number"):
+		cfg = append(cfg, "zero")
+	case nullable && !f.omitEmpty && !f.omitZero:
+		cfg = append(cfg, "null")
+	case f.omitEmpty && (basic || f.goKind == reflect.Slice || f.goKind == reflect.Map):
+		cfg = append(cfg, "omitempty")
+	case f.omitZero && (basic || f.IsDate && f.goKind == reflect.Struct):
+		cfg = append(cfg, "omitzero") // other structs can't be checked and are always serialized
+	}
+
+	if f.viaPtr {
+		cfg = append(cfg, "optional")
+	}
+
+	return strings.Join(cfg, ",")
+}
+
+// cloneExpr returns an expression that deep copies v.
+func (f *Field) cloneExpr(opts *Options, v string, depth int) (out string) {
+	switch {
+	case f.IsRaw:
+		return v
+	case f.IsDate && !opts.NoDate:
+		out = "new Date(" + v + ".getTime())"
+	case f.TsType == "object" && f.ValType != "":
+		out = v + ".clone()"
+	case f.TsType == "array", f.TsType == "tuple":
+		ev := varName("v", depth)
+		if e := f.Elem.cloneExpr(opts, ev, depth+1); e != ev {
+			out = fmt.Sprintf("%s.map((%s%s) => %s)", v, ev, TypeSuffix("any", opts.ES6, false), e)
+		} else {
+			out = v + ".slice()"
+		}
+	case f.TsType == "map":
+		ev := varName("v", depth)
+		if e := f.Elem.cloneExpr(opts, ev, depth+1); e != ev {
+			out = fmt.Sprintf("ParseMap(%s, (%s%s) => %s)", v, ev, TypeSuffix("any", opts.ES6, false), e)
+		} else {
+			out = "Object.assign({}, " + v + ")"
+		}
+	default:
+		return v
+	}
+
+	if f.CanBeNull || f.isPtr || f.canBeUndefined() {
+		out = fmt.Sprintf("(%s == null ? %s : %s)", v, v, out)
 	}
-	io.WriteString(w, ";\n")
 
 	return
 }
 
+// notEqualsExpr returns an expression that checks if the field differs between `this` and `other`.
+func (f *Field) notEqualsExpr(opts *Options) string {
+	a, b := "this."+f.Name, "other."+f.Name
+	switch eq := f.equalsExpr(opts, a, b, 0); {
+	case eq == a+" === "+b:
+		return a + " !== " + b
+	case strings.HasPrefix(eq, "JSON.stringify("):
+		return strings.Replace(eq, " === ", " !== ", 1)
+	default:
+		return "!" + eq
+	}
+}
+
+// equalsExpr returns an expression that checks if a and b are equal.
+func (f *Field) equalsExpr(opts *Options, a, b string, depth int) string {
+	switch {
+	case f.IsRaw, f.TsType == "object" && f.ValType == "", f.isCustom():
+		return fmt.Sprintf("JSON.stringify(%s) === JSON.stringify(%s)", a, b)
+	case f.IsDate && !opts.NoDate:
+		return fmt.Sprintf("DateEquals(%s, %s)", a, b)
+	case f.TsType == "object":
+		return fmt.Sprintf("(%s == null ? %s === %s : %s.equals(%s))", a, a, b, a, b)
+	case f.TsType == "array", f.TsType == "tuple", f.TsType == "map":
+		fn := "ArrayEquals"
+		if f.TsType == "map" {
+			fn = "MapEquals"
+		}
+
+		ea, eb := varName("a", depth), varName("b", depth)
+		if e := f.Elem.equalsExpr(opts, ea, eb, depth+1); e != ea+" === "+eb {
+			suffix := TypeSuffix("any", opts.ES6, false)
+			return fmt.Sprintf("%s(%s, %s, (%s%s, %s%s) => %s)", fn, a, b, ea, suffix, eb, suffix, e)
+		}
+		return fmt.Sprintf("%s(%s, %s)", fn, a, b)
+	default:
+		return a + " === " + b
+	}
+}
+
+// eager returns the struct field that gets instantiated even if it's missing from the input data,
+// either f itself or the element of a tuple, or nil if there isn't one.
+func (f *Field) eager() *Field {
+	for f.TsType == "tuple" {
+		f = f.Elem
+	}
+
+	if f.TsType == "object" && f.ValType != "" && !f.CanBeNull && !f.IsRaw {
+		return f
+	}
+
+	return nil
+}
+
 func (f *Field) DefaultValue() string {
-	if f.CanBeNull {
+	if f.Override != nil && f.Override.Default != "" {
+		return f.Override.Default
+	}
+
+	if f.viaPtr {
+		return "undefined"
+	}
+
+	if f.CanBeNull || f.isCustom() {
 		return "null"
 	}
 
 	if f.IsDate {
+		switch f.zeroDate {
+		case ZeroDateEpoch:
+			return "new Date(0)"
+		case ZeroDateUndefined:
+			return "undefined"
+		}
 		return "new Date()"
 	}
 
@@ -126,19 +515,40 @@ func (f *Field) DefaultValue() string {
 		return "new " + f.ValType + "()"
 	}
 
+	if f.TsType == "tuple" {
+		return "[" + f.repeat(f.Elem.DefaultValue()) + "]"
+	}
+
 	return zeroValues[f.TsType]
 }
 
+// isCustom reports whether f's TS type comes from its Override.
+func (f *Field) isCustom() bool {
+	return f.Override != nil && f.Override.Type != ""
+}
+
 func (f *Field) IsNative() bool {
 	switch f.TsType {
-	case "array", "map":
+	case "array", "tuple", "map":
+		if f.Elem != nil {
+			return f.Elem.IsNative()
+		}
 		return IsNative(f.ValType)
 	default:
 		return IsNative(f.TsType)
 	}
 }
 
-func (f *Field) setProps(sf reflect.StructField, sft reflect.Type) (ignore bool) {
+// repeat returns v repeated f.Len times, separated by commas.
+func (f *Field) repeat(v string) string {
+	vs := make([]string, f.Len)
+	for i := range vs {
+		vs[i] = v
+	}
+	return strings.Join(vs, ", ")
+}
+
+func (f *Field) setProps(sf reflect.StructField, sft reflect.Type, tagNames []string) (ignore bool) {
 	if len(sf.Name) > 0 && !ast.IsExported(sf.Name) {
 		return true
 	}
@@ -148,37 +558,59 @@ func (f *Field) setProps(sf reflect.StructField, sft reflect.Type) (ignore bool)
 	// }
 
 	var (
-		jsonTag = strings.Split(sf.Tag.Get("json"), ",")
-		tsTag   = strings.Split(sf.Tag.Get("ts"), ",")
+		name, tagOpts, _ = fieldTag(sf, tagNames)
+		tsTag            = strings.Split(sf.Tag.Get("ts"), ",")
 	)
 
-	if ignore = len(tsTag) > 0 && tsTag[0] == "-" || len(jsonTag) > 0 && jsonTag[0] == "-"; ignore {
+	if ignore = len(tsTag) > 0 && tsTag[0] == "-" || name == "-"; ignore {
 		return
 	}
 
-	if f.Name = sf.Name; len(jsonTag) > 0 && jsonTag[0] != "" {
-		f.Name = jsonTag[0]
+	if _, ignore = computedTag(tsTag[0], ""); ignore { // see addComputed
+		return
+	}
+
+	f.goName = sf.Name
+	if f.Name = defaultName(sf.Name, tagNames); name != "" {
+		f.Name = name
 	}
 
 	f.IsDate = isDate(sft) || len(tsTag) > 0 && tsTag[0] == "date" || sft.Kind() == reflect.Int64 && strings.HasSuffix(f.Name, "TS")
+	if strings.HasPrefix(tsTag[0], "date:") { // date:unixms etc, on arrays and maps it applies to the elements
+		f.IsDate, f.DateFormat = !isContainer(sft), DateFormat(tsTag[0][len("date:"):])
+	}
 
 	f.IsRaw = isRaw(sft) || len(tsTag) > 0 && tsTag[0] == "any"
 	if f.IsRaw {
 		f.CanBeNull = false
 	}
 
-	if len(tsTag) > 1 {
-		switch tsTag[1] {
+	for _, opt := range tsTag[1:] {
+		switch opt {
 		case "no-null":
 			f.CanBeNull = false
 		case "null":
 			f.CanBeNull = true
 		case "optional":
 			f.IsOptional = true
+		case "readonly":
+			f.IsReadonly = true
+		}
+	}
+
+	for _, opt := range tagOpts {
+		switch opt {
+		case "omitempty":
+			f.omitEmpty = true
+		case "omitzero":
+			f.omitZero = true
+		case "string":
+			f.quoted = isBasic(sft.Kind()) // encoding/json ignores it on other types
 		}
 	}
 
-	f.IsOptional = f.IsOptional || len(jsonTag) > 1 && jsonTag[1] == "omitempty"
+	f.IsOptional = f.IsOptional || f.omitEmpty || f.omitZero
+	f.goKind = sf.Type.Kind()
 	f.TsType = stripType(sft)
 
 	return
@@ -205,9 +637,31 @@ func TypeSuffix(t string, es6, as bool) string {
 	return ": " + t
 }
 
+// isBasic reports whether k is a bool, number or string kind, the kinds the encoding/json string option applies to.
+func isBasic(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return k >= reflect.Int && k <= reflect.Uintptr
+	}
+}
+
+func isContainer(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return !isRaw(t)
+	default:
+		return false
+	}
+}
+
 func isDate(t reflect.Type) bool {
 	return t.Name() == "Time" && t.PkgPath() == "time"
 }
+
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
 func isRaw(t reflect.Type) bool {
-	return t.Name() == "RawMessage" && t.PkgPath() == "encoding/json"
+	return t == rawMessageType || t.Name() == "RawMessage" && t.PkgPath() == "encoding/json"
 }
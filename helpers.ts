@@ -1,14 +1,36 @@
 const maxUnixTSInSeconds = 9999999999;
+const goZeroTime = -62135596800000; // 0001-01-01T00:00:00Z
 
-function ParseDate(d: Date | number | string): Date {
+function ParseDate(d: Date | number | string, format = ''): Date {
 	if (d instanceof Date) return d;
 	if (typeof d === 'number') {
+		switch (format) {
+			case 'unix': return new Date(d * 1000);
+			case 'unixms': return new Date(d);
+			case 'unixnano': return new Date(d / 1e6);
+		}
 		if (d > maxUnixTSInSeconds) return new Date(d);
 		return new Date(d * 1000); // go ts
 	}
 	return new Date(d);
 }
 
+function ParseNullDate<Z extends null | undefined>(d: Date | number | string | null | undefined, format: string, zero: Z): Date | Z {
+	if (d == null || d === 0) return zero;
+	const t = ParseDate(d, format);
+	return t.getTime() === goZeroTime ? zero : t;
+}
+
+function FormatDate(d: Date | null, format = ''): number | string {
+	if (!d) return format === 'string' ? '0001-01-01T00:00:00Z' : 0;
+	switch (format) {
+		case 'string': return d.toISOString().replace(/\.?0+Z$/, 'Z'); // trim the zero ms like Go
+		case 'unixms': return d.getTime();
+		case 'unixnano': return d.getTime() * 1e6;
+	}
+	return Math.floor(d.getTime() / 1000);
+}
+
 function ParseNumber(v: number | string, isInt = false): number {
 	if (!v) return 0;
 	if (typeof v === 'number') return v;
@@ -21,32 +43,83 @@ function FromArray<T>(Ctor: { new (v: any): T }, data?: any[] | any, def = null)
 	return d.map((v: any) => new Ctor(v));
 }
 
+function ParseMap<T>(data: any, conv: (v: any) => T, def: { [key: string]: T } | null = null): { [key: string]: T } | null {
+	if (!data || typeof data !== 'object') return def;
+	const m: { [key: string]: T } = {};
+	for (const k of Object.keys(data)) m[k] = conv(data[k]);
+	return m;
+}
+
+// ParseObject copies the constructor input, class instances are copied like plain objects
+// so their Dates are kept as is and every field parses them in its own format.
+// Nulls are kept as well, only missing and undefined fields get their default.
+function ParseObject(o: any): any {
+	if (o == null || typeof o !== 'object' || o instanceof Date) return o;
+	if (Array.isArray(o)) return o.map(ParseObject);
+	const d: any = {};
+	for (const k of Object.keys(o)) if (o[k] !== undefined) d[k] = ParseObject(o[k]);
+	return d;
+}
+
+function DateEquals(a: Date | null, b: Date | null): boolean {
+	if (a === b) return true;
+	if (!a || !b) return false;
+	return a.getTime() === b.getTime();
+}
+
+function ArrayEquals<T>(a: ReadonlyArray<T> | null, b: ReadonlyArray<T> | null, eq = (x: T, y: T) => x === y): boolean {
+	if (a === b) return true;
+	if (!a || !b || a.length !== b.length) return false;
+	for (let i = 0; i < a.length; i++) if (!eq(a[i], b[i])) return false;
+	return true;
+}
+
+function MapEquals<T>(a: { [key: string]: T } | null, b: { [key: string]: T } | null, eq = (x: T, y: T) => x === y): boolean {
+	if (a === b) return true;
+	if (!a || !b) return false;
+	const keys = Object.keys(a);
+	if (keys.length !== Object.keys(b).length) return false;
+	for (const k of keys) if (!(k in b) || !eq(a[k], b[k])) return false;
+	return true;
+}
+
 function ToObject(o: any, typeOrCfg: any = {}, child = false): any {
-	if (o == null) return null;
+	const [type, ...flags] = typeof typeOrCfg === 'string' ? typeOrCfg.split(',') : [''];
+	if (o == null) return flags.indexOf('zero') > -1 ? FormatDate(null, type) : null;
 	if (typeof o.toObject === 'function' && child) return o.toObject();
 
 	switch (typeof o) {
 		case 'string':
-			return typeOrCfg === 'number' ? ParseNumber(o) : o;
+			return type === 'number' ? ParseNumber(o) : o;
 		case 'boolean':
 		case 'number':
-			return o;
+			return type === 'string' ? String(o) : o; // the string json option
 	}
 
-	if (o instanceof Date) {
-		return typeOrCfg === 'string' ? o.toISOString() : Math.floor(o.getTime() / 1000);
-	}
+	if (o instanceof Date) return FormatDate(o, type);
 
-	if (Array.isArray(o)) return o.map((v: any) => ToObject(v, typeOrCfg, true));
+	if (Array.isArray(o)) return o.map((v: any) => ToObject(v, type, true));
 
 	const d: any = {};
+	const isMap = typeof typeOrCfg === 'string'; // only struct fields are omitted, map elements are kept even if null
 
 	for (const k of Object.keys(o)) {
-		const v: any = o[k];
-		if (v === undefined) continue;
-		if (v === null) continue;
-		d[k] = ToObject(v, typeOrCfg[k] || {}, true);
+		const cfg: any = isMap ? type : typeOrCfg[k] || '';
+		if (o[k] === undefined && typeof cfg === 'string' && cfg.split(',').indexOf('optional') > -1) continue; // nil embedded pointer
+		const v: any = typeof cfg === 'function' ? cfg(o[k]) : ToObject(o[k], cfg, true);
+		if (isMap || !IsOmitted(v, cfg)) d[k] = v;
 	}
 
 	return d;
 }
+
+// IsOmitted reports whether encoding/json would omit a field with the serialized value v.
+function IsOmitted(v: any, cfg: any): boolean {
+	if (typeof cfg === 'function') return v === undefined; // custom conversion, like JSON.stringify
+	const flags = cfg.split(',');
+	if (v == null) return flags.indexOf('null') === -1;
+	if (flags[0] === 'string' && (v === '0' || v === 'false')) v = 0; // the string json option, omitempty and omitzero check the value
+	if (flags.indexOf('omitempty') > -1) return typeof v === 'object' ? !Object.keys(v).length : !v;
+	if (flags.indexOf('omitzero') > -1) return !v || v === '0001-01-01T00:00:00Z';
+	return false;
+}
@@ -0,0 +1,256 @@
+package struct2ts
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/constant"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// IRVersion is the version of the IR JSON format, it changes whenever the format changes in an incompatible way.
+const IRVersion = 1
+
+// IR is the type model built by StructToTS, it can be written as JSON so other tools can use it,
+// and loaded back with FromIR to render it.
+type IR struct {
+	Version int         `json:"version"`
+	Options *Options    `json:"options"`
+	Structs []*IRStruct `json:"structs"`
+
+	// Aliases are the named types that aren't structs used by the fields, like `type Status string`.
+	Aliases []*IRAlias `json:"aliases,omitempty"`
+
+	// Enums are the aliases of basic types that have constants declared in their package.
+	Enums []*IREnum `json:"enums,omitempty"`
+}
+
+// IRStruct is a struct in the IR.
+type IRStruct struct {
+	Name    string   `json:"name"` // the TS name
+	Package string   `json:"package"`
+	GoName  string   `json:"goName"`
+	Extends []string `json:"extends,omitempty"` // the TS names of the extended structs, see Options.ExtendEmbedded
+	Fields  []*Field `json:"fields"`
+	Pos     *IRPos   `json:"pos,omitempty"`
+
+	Computed []*Computed `json:"computed,omitempty"`
+}
+
+// IRAlias is a named type that isn't a struct.
+type IRAlias struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	Type    string `json:"type"` // the TS type
+	Pos     *IRPos `json:"pos,omitempty"`
+}
+
+// IREnum lists the constants of an alias.
+type IREnum struct {
+	Name    string         `json:"name"`
+	Package string         `json:"package"`
+	Values  []*IREnumValue `json:"values"`
+}
+
+// IREnumValue is a constant, Value is a string, a number or a bool.
+type IREnumValue struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// IRPos is the position of a declaration in the Go source, File is relative to the package's directory.
+type IRPos struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// IR returns the type model of all the added types, source positions and enums are found by parsing
+// the source of the types' packages, they're left out if it can't be found.
+func (s *StructToTS) IR() *IR {
+	var (
+		ir   = &IR{Version: IRVersion, Options: s.opts}
+		pkgs = goPackages{}
+	)
+
+	for _, st := range s.structs {
+		ist := &IRStruct{
+			Name:     st.Name,
+			Package:  st.pkg,
+			GoName:   st.goName,
+			Fields:   st.Fields,
+			Pos:      irPos(st.pos),
+			Computed: st.Computed,
+		}
+
+		if ist.Pos == nil {
+			if p, ok := pkgs.get(st.pkg).decls[genericName(st.goName)]; ok {
+				ist.Pos = irPos(&p)
+			}
+		}
+
+		for _, e := range st.Embeds {
+			ist.Extends = append(ist.Extends, e.Name)
+		}
+
+		ir.Structs = append(ir.Structs, ist)
+	}
+
+	aliases := make([]reflect.Type, 0, len(s.aliases))
+	for t := range s.aliases {
+		aliases = append(aliases, t)
+	}
+	sort.Slice(aliases, func(i, j int) bool { return typePath(aliases[i]) < typePath(aliases[j]) })
+
+	for _, t := range aliases {
+		f := *s.aliases[t]
+		f.CanBeNull, f.IsReadonly = false, false
+
+		pkg := pkgs.get(t.PkgPath())
+		ia := &IRAlias{Name: t.Name(), Package: t.PkgPath(), Type: f.Type(s.opts, true)}
+		if p, ok := pkg.decls[t.Name()]; ok {
+			ia.Pos = irPos(&p)
+		}
+		ir.Aliases = append(ir.Aliases, ia)
+
+		if k := t.Kind(); k > reflect.Complex128 && k != reflect.String {
+			continue
+		}
+
+		var values []*IREnumValue
+		for _, c := range pkg.consts(t.Name()) {
+			values = append(values, &IREnumValue{Name: c.Name(), Value: constValue(c.Val())})
+		}
+		if len(values) > 0 {
+			ir.Enums = append(ir.Enums, &IREnum{Name: t.Name(), Package: t.PkgPath(), Values: values})
+		}
+	}
+
+	return ir
+}
+
+// WriteIR writes s.IR() as indented JSON.
+func (s *StructToTS) WriteIR(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(s.IR())
+}
+
+// ReadIR reads an IR written by WriteIR, it fails if it was written with a different IRVersion.
+func ReadIR(r io.Reader) (*IR, error) {
+	var ir IR
+	if err := json.NewDecoder(r).Decode(&ir); err != nil {
+		return nil, err
+	}
+
+	if ir.Version != IRVersion {
+		return nil, fmt.Errorf("unsupported IR version %d, expected %d", ir.Version, IRVersion)
+	}
+
+	return &ir, nil
+}
+
+// FromIR returns a StructToTS that renders the structs of ir, using opts if it isn't nil or the options the IR was built with.
+// Custom TS (CustomTypescript) can't be rendered since it needs the Go types.
+func FromIR(ir *IR, opts *Options) (*StructToTS, error) {
+	if opts == nil {
+		opts = ir.Options
+	}
+
+	var (
+		s      = New(opts)
+		byName = map[string]*Struct{}
+	)
+
+	for _, ist := range ir.Structs {
+		if byName[ist.Name] != nil {
+			return nil, fmt.Errorf("duplicate struct %s in the IR", ist.Name)
+		}
+
+		st := &Struct{Name: ist.Name, Fields: ist.Fields, Computed: ist.Computed, pkg: ist.Package, goName: ist.GoName}
+		if p := ist.Pos; p != nil {
+			st.pos = &sourcePos{pkg: ist.Package, file: p.File, line: p.Line, column: p.Column}
+		}
+
+		byName[st.Name] = st
+		s.structs = append(s.structs, st)
+	}
+
+	for i, ist := range ir.Structs {
+		for _, name := range ist.Extends {
+			e := byName[name]
+			if e == nil {
+				return nil, fmt.Errorf("%s extends %s which isn't in the IR", ist.Name, name)
+			}
+			s.structs[i].Embeds = append(s.structs[i].Embeds, e)
+		}
+	}
+
+	return s, nil
+}
+
+func irPos(p *sourcePos) *IRPos {
+	if p == nil {
+		return nil
+	}
+	return &IRPos{File: p.file, Line: p.line, Column: p.column}
+}
+
+func constValue(v constant.Value) interface{} {
+	switch v.Kind() {
+	case constant.String:
+		return constant.StringVal(v)
+	case constant.Bool:
+		return constant.BoolVal(v)
+	case constant.Int:
+		if i, ok := constant.Int64Val(v); ok {
+			return i
+		}
+	case constant.Float:
+		if f, ok := constant.Float64Val(v); ok {
+			return f
+		}
+	}
+	return v.ExactString()
+}
+
+// fieldAlias is Field without its json methods.
+type fieldAlias Field
+
+// fieldJSON adds the unexported fields needed to render a Field to its JSON.
+type fieldJSON struct {
+	*fieldAlias
+	IsPtr     bool     `json:"isPtr,omitempty"`
+	OmitEmpty bool     `json:"omitEmpty,omitempty"`
+	OmitZero  bool     `json:"omitZero,omitempty"`
+	GoKind    string   `json:"goKind,omitempty"`
+	ZeroDate  ZeroDate `json:"zeroDate,omitempty"`
+	ViaPtr    bool     `json:"viaPtr,omitempty"`
+	Quoted    bool     `json:"quoted,omitempty"`
+}
+
+func (f *Field) MarshalJSON() ([]byte, error) {
+	fj := fieldJSON{(*fieldAlias)(f), f.isPtr, f.omitEmpty, f.omitZero, "", f.zeroDate, f.viaPtr, f.quoted}
+	if f.goKind != reflect.Invalid {
+		fj.GoKind = f.goKind.String()
+	}
+	return json.Marshal(fj)
+}
+
+func (f *Field) UnmarshalJSON(data []byte) error {
+	fj := fieldJSON{fieldAlias: (*fieldAlias)(f)}
+	if err := json.Unmarshal(data, &fj); err != nil {
+		return err
+	}
+
+	f.isPtr, f.omitEmpty, f.omitZero, f.zeroDate, f.viaPtr = fj.IsPtr, fj.OmitEmpty, fj.OmitZero, fj.ZeroDate, fj.ViaPtr
+	f.quoted = fj.Quoted
+	for k := reflect.Invalid; k <= reflect.UnsafePointer; k++ {
+		if k.String() == fj.GoKind {
+			f.goKind = k
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package struct2ts
+
+import "sort"
+
+// Order is the order the structs are rendered in, see Options.Order.
+type Order string
+
+const (
+	// OrderDefault renders the structs in the order they were added, with the types they use before them.
+	OrderDefault Order = ""
+	// OrderTopological renders dependencies first, ties and cycles are broken alphabetically.
+	OrderTopological Order = "topological"
+	// OrderAlphabetical renders the structs sorted by name.
+	OrderAlphabetical Order = "alpha"
+	// OrderSource renders the structs in the order they are declared in the Go source,
+	// types that can't be found in the source go last, sorted by name.
+	OrderSource Order = "source"
+)
+
+// sortStructs returns structs sorted by order, classes always come after the classes they extend.
+func sortStructs(structs []*Struct, order Order) []*Struct {
+	out := append([]*Struct(nil), structs...)
+	byName := func(i, j int) bool { return out[i].Name < out[j].Name }
+
+	switch order {
+	case OrderTopological:
+		sort.Slice(out, byName)
+		out = topological(out)
+	case OrderAlphabetical:
+		sort.Slice(out, byName)
+	case OrderSource:
+		sort.Slice(out, byName)
+		pos := sourcePositions(out)
+		sort.SliceStable(out, func(i, j int) bool {
+			a, aok := pos[out[i]]
+			b, bok := pos[out[j]]
+			switch {
+			case aok && bok:
+				return a.less(b)
+			default:
+				return aok && !bok
+			}
+		})
+	}
+
+	return extendsFirst(out)
+}
+
+// topological returns the structs with every struct coming after the structs its fields use,
+// structs must already be sorted by name.
+func topological(structs []*Struct) []*Struct {
+	var (
+		out    = make([]*Struct, 0, len(structs))
+		byName = structsByName(structs)
+		seen   = map[*Struct]bool{}
+		visit  func(st *Struct)
+	)
+
+	visit = func(st *Struct) {
+		if seen[st] {
+			return
+		}
+		seen[st] = true
+		for _, dep := range st.deps(byName) {
+			visit(dep)
+		}
+		out = append(out, st)
+	}
+
+	for _, st := range structs {
+		visit(st)
+	}
+
+	return out
+}
+
+// deps returns the structs that s extends or uses in its fields, sorted by name.
+func (s *Struct) deps(byName map[string]*Struct) []*Struct {
+	var (
+		out  = append([]*Struct(nil), s.Embeds...)
+		seen = map[string]bool{}
+	)
+
+	for _, f := range s.Fields {
+		for ; f != nil; f = f.Elem {
+			if f.TsType != "object" || f.ValType == "" || seen[f.ValType] {
+				continue
+			}
+			seen[f.ValType] = true
+			if dep := byName[f.ValType]; dep != nil && dep != s {
+				out = append(out, dep)
+			}
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func structsByName(structs []*Struct) map[string]*Struct {
+	byName := make(map[string]*Struct, len(structs))
+	for _, st := range structs {
+		byName[st.Name] = st
+	}
+	return byName
+}
+
+type sourcePos struct {
+	pkg, file    string
+	line, column int
+}
+
+func (p sourcePos) less(o sourcePos) bool {
+	if p.pkg != o.pkg {
+		return p.pkg < o.pkg
+	}
+	if p.file != o.file {
+		return p.file < o.file
+	}
+	if p.line != o.line {
+		return p.line < o.line
+	}
+	return p.column < o.column
+}
+
+// sourcePositions finds where the structs are declared by parsing the source of their packages.
+func sourcePositions(structs []*Struct) map[*Struct]sourcePos {
+	var (
+		out  = map[*Struct]sourcePos{}
+		pkgs = goPackages{}
+	)
+
+	for _, st := range structs {
+		if st.pos != nil { // loaded from an IR
+			out[st] = *st.pos
+			continue
+		}
+
+		if p, ok := pkgs.get(st.pkg).decls[genericName(st.goName)]; ok {
+			out[st] = p
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,493 @@
+package struct2ts
+
+import (
+	"fmt"
+	"io"
+)
+
+// Renderer renders the added types for an output target, StructToTS.RenderTo outputs RenderPrologue once,
+// then RenderStructStart, RenderField for each of the fields and RenderStructEnd for every struct
+// (separating them with a blank line) and finally RenderEpilogue.
+// The structs are rendered before RenderPrologue is called, so it only imports what their custom output uses.
+//
+// Set Options.Renderer to use another target, the built-in renderers can be embedded to only replace parts of their output.
+type Renderer interface {
+	RenderPrologue(w io.Writer, structs []*Struct) error
+	RenderStructStart(w io.Writer, s *Struct) error
+	RenderField(w io.Writer, s *Struct, f *Field) error
+	RenderStructEnd(w io.Writer, s *Struct) error
+	RenderEpilogue(w io.Writer, structs []*Struct) error
+}
+
+// DefaultRenderer returns the built-in renderer that matches opts.
+func DefaultRenderer(opts *Options) Renderer {
+	switch {
+	case opts.ES6:
+		return NewES6Renderer(opts)
+	case opts.InterfaceOnly:
+		return NewInterfaceRenderer(opts)
+	default:
+		return NewClassRenderer(opts)
+	}
+}
+
+func (opts *Options) renderer() Renderer {
+	if opts.Renderer != nil {
+		return opts.Renderer
+	}
+	return DefaultRenderer(opts)
+}
+
+// ClassRenderer renders TS classes.
+type ClassRenderer struct {
+	opts *Options
+	es6  bool // no type annotations, see ES6Renderer
+}
+
+func NewClassRenderer(opts *Options) *ClassRenderer {
+	return &ClassRenderer{opts: opts}
+}
+
+// classRenderer returns the class renderer that matches opts.
+func classRenderer(opts *Options) *ClassRenderer {
+	return &ClassRenderer{opts: opts, es6: opts.ES6}
+}
+
+func (r *ClassRenderer) RenderPrologue(w io.Writer, structs []*Struct) error {
+	return renderHelpers(w, r.opts, structs, ts_helpers)
+}
+
+func (r *ClassRenderer) RenderStructStart(w io.Writer, s *Struct) (err error) {
+	ew := newErrWriter(w)
+	w = ew
+
+	fmt.Fprintf(w, "// struct2ts:%s.%s\n", s.pkg, s.Name)
+
+	if err = r.renderInit(w, s); err != nil {
+		return
+	}
+
+	fmt.Fprintf(w, "class %s%s {\n", s.Name, s.extends())
+	return ew.err
+}
+
+func (r *ClassRenderer) RenderField(w io.Writer, _ *Struct, f *Field) error {
+	return renderDecl(w, r.opts, f, r.opts.NoConstructor && !r.opts.NoAssignDefaults)
+}
+
+func (r *ClassRenderer) RenderStructEnd(w io.Writer, s *Struct) (err error) {
+	ew := newErrWriter(w)
+	w = ew
+
+	for _, fn := range []func(io.Writer, *Struct) error{
+		r.renderDeclared,
+		r.renderConstructor,
+		r.renderFactories,
+		r.renderToObject,
+		r.renderWith,
+		r.renderClone,
+		r.renderEquals,
+		r.renderGetters,
+	} {
+		if err = fn(w, s); err != nil {
+			return
+		}
+	}
+
+	if err = s.RenderCustom(r.opts, w); err != nil {
+		return
+	}
+
+	io.WriteString(w, "}")
+	return ew.err
+}
+
+func (r *ClassRenderer) RenderEpilogue(w io.Writer, structs []*Struct) (err error) {
+	if r.opts.NoExports {
+		return
+	}
+
+	ew := newErrWriter(w)
+	w = ew
+
+	io.WriteString(w, "// exports\nexport {\n")
+	for _, st := range structs {
+		fmt.Fprintf(w, "%s%s,\n", r.opts.indents[1], st.Name)
+	}
+	if !r.opts.NoHelpers {
+		for _, n := range helperNames {
+			fmt.Fprintf(w, "%s%s,\n", r.opts.indents[1], n)
+		}
+	}
+	io.WriteString(w, "};\n")
+
+	return ew.err
+}
+
+// renderInit renders the `XInit` interface describing the constructor input, see Options.TypedInit.
+func (r *ClassRenderer) renderInit(w io.Writer, s *Struct) (err error) {
+	opts := r.opts
+	if !opts.TypedInit || opts.NoConstructor || r.es6 {
+		return
+	}
+
+	ew := newErrWriter(w)
+	w = ew
+
+	if !opts.NoExports {
+		io.WriteString(w, "export ")
+	}
+
+	fmt.Fprintf(w, "interface %sInit%s {\n", s.Name, s.extends("Init"))
+	for _, f := range s.Fields {
+		fmt.Fprintf(w, "%s%s: %s;\n", opts.indents[1], f.Name, f.InitType(opts))
+	}
+	io.WriteString(w, "}\n\n")
+
+	return ew.err
+}
+
+func (r *ClassRenderer) renderConstructor(w io.Writer, s *Struct) (err error) {
+	opts := r.opts
+	if opts.NoConstructor {
+		return
+	}
+
+	ew := newErrWriter(w)
+	w = ew
+
+	if r.es6 { // no fields are declared before it
+		fmt.Fprintf(w, "%sconstructor(data = null) {\n", opts.indents[1])
+	} else {
+		dt := "any"
+		if opts.TypedInit {
+			dt = "Partial<" + s.Name + "Init>"
+		}
+		fmt.Fprintf(w, "\n%sconstructor(data?: %s) {\n", opts.indents[1], dt)
+	}
+
+	if len(s.Embeds) > 0 {
+		fmt.Fprintf(w, "%ssuper(data);\n", opts.indents[2])
+	}
+
+	fmt.Fprintf(w, "%sconst d%s = (data && typeof data === 'object') ? ParseObject(data) : {};\n", opts.indents[2], r.typed(": any"))
+
+	for _, f := range s.Fields {
+		if err = f.RenderCtor(w, opts); err != nil {
+			return
+		}
+	}
+
+	if opts.Freeze { // subclasses still have to set their own fields
+		fmt.Fprintf(w, "%sif (new.target === %s) Object.freeze(this);\n", opts.indents[2], s.Name)
+	}
+
+	fmt.Fprintf(w, "%s}\n", opts.indents[1])
+	return ew.err
+}
+
+// renderFactories renders the static fromJSON and fromArray methods.
+func (r *ClassRenderer) renderFactories(w io.Writer, s *Struct) (err error) {
+	opts := r.opts
+	if opts.NoFactories || opts.NoConstructor {
+		return
+	}
+
+	ew := newErrWriter(w)
+	w = ew
+
+	fmt.Fprintf(w, "\n%sstatic fromJSON(json%s)%s {\n", opts.indents[1], r.typed(": string"), r.typed(": "+s.Name))
+	fmt.Fprintf(w, "%sreturn new %s(JSON.parse(json));\n%s}\n", opts.indents[2], s.Name, opts.indents[1])
+
+	fmt.Fprintf(w, "\n%sstatic fromArray(data%s)%s {\n", opts.indents[1], r.typed("?: any[] | any"), r.typed(": "+s.Name+"[] | null"))
+	fmt.Fprintf(w, "%sreturn FromArray(%s, data);\n%s}\n", opts.indents[2], s.Name, opts.indents[1])
+	return ew.err
+}
+
+func (r *ClassRenderer) renderToObject(w io.Writer, s *Struct) (err error) {
+	opts := r.opts
+	if opts.NoToObject {
+		return
+	}
+
+	ew := newErrWriter(w)
+	w = ew
+
+	fmt.Fprintf(w, "\n%stoObject()%s {\n", opts.indents[1], r.typed(": any"))
+	fmt.Fprintf(w, "%sconst cfg%s = {};\n", opts.indents[2], r.typed(": any"))
+	s.renderCfg(opts, w)
+	fmt.Fprintf(w, "%sreturn ToObject(this, cfg);\n%s}\n", opts.indents[2], opts.indents[1])
+
+	// makes JSON.stringify(instance) match toObject()
+	fmt.Fprintf(w, "\n%stoJSON()%s {\n", opts.indents[1], r.typed(": any"))
+	fmt.Fprintf(w, "%sreturn this.toObject();\n%s}\n", opts.indents[2], opts.indents[1])
+	return ew.err
+}
+
+// renderWith renders a `with(patch)` method that returns a shallow copy of the instance with patch applied, see Options.Readonly.
+func (r *ClassRenderer) renderWith(w io.Writer, s *Struct) (err error) {
+	opts := r.opts
+	if !opts.Readonly {
+		return
+	}
+
+	ew := newErrWriter(w)
+	w = ew
+
+	fmt.Fprintf(w, "\n%swith(patch%s)%s {\n", opts.indents[1], r.typed(": Partial<"+s.Name+">"), r.typed(": "+s.Name))
+	fmt.Fprintf(w, "%sconst o = Object.assign(Object.create(Object.getPrototypeOf(this)), this, patch);\n", opts.indents[2])
+	fmt.Fprintf(w, "%sreturn Object.isFrozen(this) ? Object.freeze(o) : o;\n", opts.indents[2])
+	fmt.Fprintf(w, "%s}\n", opts.indents[1])
+	return ew.err
+}
+
+// renderClone renders a `clone()` method that returns a deep copy of the instance, see Options.Clone.
+func (r *ClassRenderer) renderClone(w io.Writer, s *Struct) (err error) {
+	opts := r.opts
+	if !opts.Clone {
+		return
+	}
+
+	ew := newErrWriter(w)
+	w = ew
+
+	o := "Object.create(Object.getPrototypeOf(this))"
+	if len(s.Embeds) > 0 {
+		o = "super.clone()"
+	}
+
+	fmt.Fprintf(w, "\n%sclone()%s {\n", opts.indents[1], r.typed(": "+s.Name))
+	fmt.Fprintf(w, "%sconst o%s = %s;\n", opts.indents[2], r.typed(": any"), o)
+
+	for _, f := range s.Fields {
+		fmt.Fprintf(w, "%so.%s = %s;\n", opts.indents[2], f.Name, f.cloneExpr(opts, "this."+f.Name, 0))
+	}
+
+	if opts.Freeze {
+		fmt.Fprintf(w, "%sif (this.constructor === %s && Object.isFrozen(this)) Object.freeze(o);\n", opts.indents[2], s.Name)
+	}
+
+	fmt.Fprintf(w, "%sreturn o;\n%s}\n", opts.indents[2], opts.indents[1])
+	return ew.err
+}
+
+// renderEquals renders an `equals(other)` method that compares the instances field by field, see Options.Equals.
+func (r *ClassRenderer) renderEquals(w io.Writer, s *Struct) (err error) {
+	opts := r.opts
+	if !opts.Equals {
+		return
+	}
+
+	ew := newErrWriter(w)
+	w = ew
+
+	fmt.Fprintf(w, "\n%sequals(other%s)%s {\n", opts.indents[1], r.typed("?: "+s.Name+" | null"), r.typed(": boolean"))
+	fmt.Fprintf(w, "%sif (this === other) return true;\n", opts.indents[2])
+	fmt.Fprintf(w, "%sif (!other) return false;\n", opts.indents[2])
+	if len(s.Embeds) > 0 {
+		fmt.Fprintf(w, "%sif (!super.equals(other)) return false;\n", opts.indents[2])
+	}
+
+	for _, f := range s.Fields {
+		fmt.Fprintf(w, "%sif (%s) return false;\n", opts.indents[2], f.notEqualsExpr(opts))
+	}
+
+	fmt.Fprintf(w, "%sreturn true;\n%s}\n", opts.indents[2], opts.indents[1])
+	return ew.err
+}
+
+// renderDeclared declares the getters that couldn't be translated, so they can be implemented by hand.
+func (r *ClassRenderer) renderDeclared(w io.Writer, s *Struct) (err error) {
+	if r.es6 { // nothing to declare in js
+		return
+	}
+
+	ew := newErrWriter(w)
+	w = ew
+
+	for _, c := range s.Computed {
+		if c.Expr == "" {
+			fmt.Fprintf(w, "%sdeclare readonly %s: %s;\n", r.opts.indents[1], c.Name, c.Result.Type(r.opts, false))
+		}
+	}
+
+	return ew.err
+}
+
+// renderGetters renders the getters translated from Go methods.
+func (r *ClassRenderer) renderGetters(w io.Writer, s *Struct) (err error) {
+	opts := r.opts
+	ew := newErrWriter(w)
+	w = ew
+
+	for _, c := range s.Computed {
+		if c.Expr == "" {
+			continue
+		}
+		fmt.Fprintf(w, "\n%sget %s()%s {\n", opts.indents[1], c.Name, r.typed(": "+c.Result.Type(opts, false)))
+		fmt.Fprintf(w, "%sreturn %s;\n%s}\n", opts.indents[2], c.Expr, opts.indents[1])
+	}
+
+	return ew.err
+}
+
+// typed returns the type annotation a, or nothing for ES6.
+func (r *ClassRenderer) typed(a string) string {
+	if r.es6 {
+		return ""
+	}
+	return a
+}
+
+// ES6Renderer renders ES6 classes, the fields aren't declared and InterfaceOnly only leaves the struct2ts comments.
+type ES6Renderer struct {
+	ClassRenderer
+}
+
+func NewES6Renderer(opts *Options) *ES6Renderer {
+	return &ES6Renderer{ClassRenderer{opts: opts, es6: true}}
+}
+
+func (r *ES6Renderer) RenderPrologue(w io.Writer, structs []*Struct) error {
+	if _, err := io.WriteString(w, "'use strict';\n"); err != nil {
+		return err
+	}
+	return renderHelpers(w, r.opts, structs, es6_helpers)
+}
+
+func (r *ES6Renderer) RenderStructStart(w io.Writer, s *Struct) (err error) {
+	ew := newErrWriter(w)
+	w = ew
+
+	fmt.Fprintf(w, "// struct2ts:%s.%s\n", s.pkg, s.Name)
+	if !r.opts.InterfaceOnly { // no interfaces in js
+		fmt.Fprintf(w, "class %s%s {\n", s.Name, s.extends())
+	}
+
+	return ew.err
+}
+
+func (r *ES6Renderer) RenderField(io.Writer, *Struct, *Field) error { return nil }
+
+func (r *ES6Renderer) RenderStructEnd(w io.Writer, s *Struct) error {
+	if r.opts.InterfaceOnly {
+		return nil
+	}
+	return r.ClassRenderer.RenderStructEnd(w, s)
+}
+
+func (r *ES6Renderer) RenderEpilogue(w io.Writer, structs []*Struct) (err error) {
+	opts := r.opts
+	if opts.NoExports || opts.InterfaceOnly && opts.NoHelpers {
+		return
+	}
+
+	ew := newErrWriter(w)
+	w = ew
+
+	io.WriteString(w, "// exports\nif (typeof exports === 'undefined') var exports = {};\n\n")
+
+	export := func(n string) { fmt.Fprintf(w, "exports.%s = %s;\n", n, n) }
+	if !opts.InterfaceOnly {
+		for _, st := range structs {
+			export(st.Name)
+		}
+	}
+	if !opts.NoHelpers {
+		for _, n := range helperNames {
+			export(n)
+		}
+	}
+
+	return ew.err
+}
+
+// InterfaceRenderer renders TS interfaces.
+type InterfaceRenderer struct {
+	opts *Options
+}
+
+func NewInterfaceRenderer(opts *Options) *InterfaceRenderer {
+	return &InterfaceRenderer{opts: opts}
+}
+
+func (r *InterfaceRenderer) RenderPrologue(w io.Writer, structs []*Struct) error {
+	return renderHelpers(w, r.opts, structs, ts_helpers)
+}
+
+func (r *InterfaceRenderer) RenderStructStart(w io.Writer, s *Struct) (err error) {
+	ew := newErrWriter(w)
+	w = ew
+
+	fmt.Fprintf(w, "// struct2ts:%s.%s\n", s.pkg, s.Name)
+	if !r.opts.NoExports {
+		io.WriteString(w, "export ")
+	}
+	fmt.Fprintf(w, "interface %s%s {\n", s.Name, s.extends())
+
+	return ew.err
+}
+
+func (r *InterfaceRenderer) RenderField(w io.Writer, _ *Struct, f *Field) error {
+	return renderDecl(w, r.opts, f, false)
+}
+
+// RenderStructEnd leaves the getters out, the objects described by the interfaces don't have them.
+func (r *InterfaceRenderer) RenderStructEnd(w io.Writer, s *Struct) (err error) {
+	if err = s.RenderCustom(r.opts, w); err != nil {
+		return
+	}
+	_, err = io.WriteString(w, "}")
+	return
+}
+
+func (r *InterfaceRenderer) RenderEpilogue(w io.Writer, _ []*Struct) (err error) {
+	// interfaces are exported inline!
+	if r.opts.NoExports || r.opts.NoHelpers {
+		return
+	}
+
+	ew := newErrWriter(w)
+	w = ew
+
+	io.WriteString(w, "// exports\nexport {\n")
+	for _, n := range helperNames {
+		fmt.Fprintf(w, "%s%s,\n", r.opts.indents[1], n)
+	}
+	io.WriteString(w, "};\n")
+
+	return ew.err
+}
+
+// renderHelpers renders the imports of structs and the helpers, followed by the start of the structs.
+func renderHelpers(w io.Writer, opts *Options, structs []*Struct, helpers string) error {
+	ew := newErrWriter(w)
+	w = ew
+
+	for _, imp := range imports(structs) {
+		io.WriteString(w, imp+"\n")
+	}
+
+	if !opts.NoHelpers {
+		io.WriteString(w, "\n// helpers")
+		io.WriteString(w, helpers)
+		io.WriteString(w, "\n")
+	}
+
+	io.WriteString(w, "// structs\n")
+	return ew.err
+}
+
+// renderDecl renders the TS declaration of f, with its default value if def is set.
+func renderDecl(w io.Writer, opts *Options, f *Field, def bool) error {
+	ew := newErrWriter(w)
+	w = ew
+
+	fmt.Fprintf(w, "%s%s: %s", opts.indents[1], f.declName(opts), f.Type(opts, false))
+	if def {
+		fmt.Fprintf(w, " = %s", f.DefaultValue())
+	}
+	io.WriteString(w, ";\n")
+
+	return ew.err
+}
@@ -4,10 +4,14 @@ package struct2ts
 
 import (
 	"bufio"
+	"bytes"
+	"encoding"
 	"fmt"
+	"go/constant"
+	"hash/fnv"
 	"io"
-	"log"
 	"reflect"
+	"sort"
 	"strings"
 	"unicode"
 )
@@ -19,17 +23,82 @@ type Options struct {
 	InterfaceOnly    bool
 
 	MarkOptional  bool
+	RecordMaps    bool
 	NoCapitalize  bool
 	NoConstructor bool
+	NoFactories   bool
 	NoToObject    bool
 	NoExports     bool
 	NoHelpers     bool
 	NoDate        bool
 	ES6           bool
 
+	// DateFormat is the wire format of dates that don't have their own (`ts:"date:unixms"`),
+	// it controls both how the ctor parses them and how toObject serializes them.
+	// The Unix formats only apply to numeric dates, time.Time is always an RFC 3339 string.
+	DateFormat DateFormat
+
+	// ZeroDate controls the default of missing dates and whether Go's zero time is treated as null or undefined.
+	ZeroDate ZeroDate
+
+	// ExtendEmbedded renders untagged embedded structs as `interface X extends Embedded` rather than flattening their fields,
+	// classes can only extend a single embedded struct, structs that embed more than one are still flattened.
+	// So are embedded pointers and structs with fields that are shadowed or conflict with another embedded struct's.
+	ExtendEmbedded bool
+
+	// Strict makes RenderTo fail if any of the added types had unsupported fields, see StructToTS.Err.
+	Strict bool
+
+	// Rename maps full Go type paths (`github.com/you/billing.Config`) to the TS names to use for them.
+	Rename map[string]string
+
+	// OnNameCollision decides what to do when two different Go types end up with the same TS name.
+	OnNameCollision CollisionStrategy
+
+	// Order is the order the structs are rendered in, the default is the order they were added in.
+	Order Order
+
+	// Readonly makes all the fields and containers readonly and adds a `with(patch)` method that returns a modified copy,
+	// fields can be made readonly individually with `ts:",readonly"`.
+	Readonly bool
+	// Freeze calls Object.freeze on new instances.
+	Freeze bool
+
+	// TypedInit generates an `XInit` interface with the wire shape of every class
+	// and types the constructors as `constructor(data?: Partial<XInit>)` instead of `any`.
+	TypedInit bool
+
+	// Clone adds a `clone()` method that returns a deep copy.
+	Clone bool
+	// Equals adds an `equals(other)` method that compares the instances field by field.
+	Equals bool
+
+	// TagNames are the struct tags the field names and options (`-`, omitempty, omitzero and inline) are read from,
+	// in priority order: `[]string{"bson", "json"}` uses the bson tag of fields that have one and the json tag otherwise.
+	// The default is json, ts is always read for its own options. Fields their tags don't name get the default name
+	// of the first tag's codec: bson and yaml lowercase them, the others keep the Go name. inline is ignored in json tags.
+	TagNames []string
+
+	// Renderer renders the output of RenderTo, the default is DefaultRenderer(opts).
+	Renderer Renderer `json:"-"`
+
 	indents [3]string
 }
 
+// CollisionStrategy is what to do when two Go types would get the same TS name,
+// for example `users.Config` and `billing.Config`.
+// The type with the first package path keeps the name, regardless of the order the types are added in.
+type CollisionStrategy string
+
+const (
+	// CollisionError fails RenderTo with a *NameCollisionError naming both Go types.
+	CollisionError CollisionStrategy = ""
+	// CollisionPackage prefixes the names of the other types with their package names (`UsersConfig`).
+	CollisionPackage CollisionStrategy = "package"
+	// CollisionHash suffixes the names of the other types with a hash of their package paths (`Config_1b2c3d4e`).
+	CollisionHash CollisionStrategy = "hash"
+)
+
 func New(opts *Options) *StructToTS {
 	if opts == nil {
 		opts = &Options{}
@@ -39,100 +108,270 @@ func New(opts *Options) *StructToTS {
 		opts.Indent = "\t"
 	}
 
+	if len(opts.TagNames) == 0 {
+		opts.TagNames = []string{"json"}
+	}
+
 	for i := range opts.indents {
 		opts.indents[i] = strings.Repeat(opts.Indent, i)
 	}
 
 	return &StructToTS{
-		seen: map[reflect.Type]*Struct{},
-		opts: opts,
+		seen:    map[reflect.Type]*Struct{},
+		names:   map[string]reflect.Type{},
+		aliases: map[reflect.Type]*Field{},
+		hooks:   map[fieldKey]FieldHook{},
+		pkgs:    goPackages{},
+		opts:    opts,
 	}
 }
 
 type StructToTS struct {
 	structs []*Struct
 	seen    map[reflect.Type]*Struct
+	names   map[string]reflect.Type
+	aliases map[reflect.Type]*Field // named non-struct types used by the fields, see IR
+	hooks   map[fieldKey]FieldHook
+	pkgs    goPackages // the parsed sources of the added types, see addComputed
 	opts    *Options
+	errs    Errors
 }
 
+// Add adds v's type, unsupported fields are skipped and reported by Err.
 func (s *StructToTS) Add(v interface{}) *Struct { return s.AddWithName(v, "") }
 
 func (s *StructToTS) AddWithName(v interface{}, name string) *Struct {
-	var t reflect.Type
+	st := s.addType(typeOf(v), name)
+	s.resolveNames()
+	return st
+}
+
+// OverrideField registers fn to override the generated code of a field of v's struct type,
+// field is the Go name of the field. It has to be called before the type is added.
+func (s *StructToTS) OverrideField(v interface{}, field string, fn FieldHook) error {
+	t := indirect(typeOf(v))
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("%s isn't a struct", t)
+	}
+
+	if _, ok := t.FieldByName(field); !ok {
+		return fmt.Errorf("%s has no field %s", typePath(t), field)
+	}
+
+	s.hooks[fieldKey{t, field}] = fn
+	return nil
+}
+
+// fieldKey is a field of a struct type, see OverrideField.
+type fieldKey struct {
+	t    reflect.Type
+	name string
+}
+
+// fieldOverride returns the override of the field sf of t, from OverrideField or the CustomTypescriptField of its type.
+func (s *StructToTS) fieldOverride(t reflect.Type, sf reflect.StructField, f *Field) *FieldOverride {
+	if fn := s.hooks[fieldKey{t, sf.Name}]; fn != nil {
+		return fn(f)
+	}
+
+	// *T has the methods of both T and *T
+	if ct, ok := reflect.New(indirect(sf.Type)).Interface().(CustomTypescriptField); ok {
+		return ct.TypescriptField(f)
+	}
+
+	return nil
+}
+
+func typeOf(v interface{}) reflect.Type {
 	switch v := v.(type) {
 	case reflect.Type:
-		t = v
+		return v
 	case reflect.Value:
-		t = v.Type()
+		return v.Type()
 	default:
-		t = reflect.TypeOf(v)
+		return reflect.TypeOf(v)
 	}
+}
 
-	return s.addType(t, name)
+// Err returns an Errors listing every unsupported field found so far, or nil.
+func (s *StructToTS) Err() error {
+	if len(s.errs) == 0 {
+		return nil
+	}
+	return s.errs
 }
 
 func (s *StructToTS) addTypeFields(out *Struct, t reflect.Type) {
-	for i := 0; i < t.NumField(); i++ {
-		sf := t.Field(i)
+	fields := jsonFields(t, s.opts.TagNames)
+
+	var embedded []int
+	if s.opts.ExtendEmbedded {
+		for i := 0; i < t.NumField(); i++ {
+			// the fields of nil pointers are omitted, so they can't be required by the embedded type
+			if sf := t.Field(i); sf.Type.Kind() != reflect.Ptr && isInlined(sf, s.opts.TagNames) && promotesAll(t, i, fields, s.opts.TagNames) {
+				embedded = append(embedded, i)
+			}
+		}
+
+		// classes can only extend one class, fallback to flattening
+		if len(embedded) > 1 && !s.opts.InterfaceOnly {
+			embedded = nil
+		}
+	}
+
+	embeds := map[int]bool{}
+	for _, i := range embedded {
+		embeds[i] = true
+		out.Embeds = append(out.Embeds, s.addType(t.Field(i).Type, ""))
+	}
+
+	for _, jf := range fields {
+		if len(jf.Index) > 1 && embeds[jf.Index[0]] {
+			continue
+		}
+
+		sf := jf.StructField
 		sft := sf.Type
 		k := sft.Kind()
 		var tf Field
 
 		if k == reflect.Ptr {
-			tf.CanBeNull = true
+			tf.CanBeNull, tf.isPtr = true, true
 			sft = indirect(sft)
 			k = sft.Kind()
 		}
 
-		if tf.setProps(sf, sft) {
+		if tf.setProps(sf, sft, s.opts.TagNames) {
 			continue
 		}
+		tf.viaPtr = jf.viaPtr
+		tf.IsOptional = tf.IsOptional || tf.viaPtr
 
-		if sf.Anonymous && k == reflect.Struct && !tf.IsDate {
-			// log.Println("trying anonymous field:", sft, k)
-			s.addTypeFields(out, sft)
+		if tf.Override = s.fieldOverride(t, sf, &tf); tf.isCustom() {
+			// the Go type is replaced, so it's not converted at all
+			tf.TsType, tf.IsDate, tf.IsRaw = tf.Override.Type, false, false
+			out.Fields = append(out.Fields, &tf)
 			continue
 		}
 
 		switch {
-		case k == reflect.Map:
-			tf.TsType, tf.KeyType, tf.ValType = "map", stripType(sft.Key()), stripType(sft.Elem())
-
-			switch {
-			case isStruct(sft.Elem()):
-				tf.ValType = s.addType(sft.Elem(), "").Name
-			case sft.Elem().Kind() == reflect.Interface:
-				tf.ValType = "any"
-			}
+		case tf.IsRaw:
+		case k == reflect.Slice, k == reflect.Map, k == reflect.Interface: // encoding/json encodes nil ones as null
+			tf.CanBeNull = true
+		case k == reflect.Array:
+			tf.CanBeNull = false
+		}
 
-		case k == reflect.Slice, k == reflect.Array:
-			if isRaw(sft) || tf.IsRaw {
-				break
-			}
-			tf.CanBeNull = k == reflect.Slice
-			tf.TsType, tf.ValType = "array", stripType(sft.Elem())
+		if err := s.setType(&tf, sft, typePath(t)+"."+sf.Name); err != nil {
+			s.errs = append(s.errs, err)
+			continue
+		}
+		s.addAlias(sft, &tf)
 
-			if isStruct(sft.Elem()) {
-				tf.ValType = s.addType(sft.Elem(), "").Name
-			}
+		if !tf.validDateFormat() {
+			s.errs = append(s.errs, &InvalidDateFormatError{Path: typePath(t) + "." + sf.Name, Format: tf.DateFormat})
+			continue
+		}
+
+		if tf.quoted && (tf.TsType == "string" || tf.IsDate) { // strings keep their quoted value, dates their quoted number
+			s.errs = append(s.errs, &UnsupportedOptionError{Path: typePath(t) + "." + sf.Name, Option: "string"})
+			tf.quoted, tf.IsDate = tf.TsType != "string", false
+		}
+
+		for e := tf.Elem; e != nil && tf.IsReadonly; e = e.Elem {
+			e.IsReadonly = true
+		}
+		out.Fields = append(out.Fields, &tf)
+	}
+}
 
-		case k == reflect.Struct:
-			if isDate(sft) || tf.IsDate {
-				break
+// setType fills in the type info of f from t, recursing into the element types of arrays and maps.
+// path is the Go path of the field, used for errors.
+func (s *StructToTS) setType(f *Field, t reflect.Type, path string) (err error) {
+	if f.IsDate && !s.opts.NoDate {
+		if f.zeroDate = s.opts.ZeroDate; f.zeroDate == ZeroDateNull {
+			f.CanBeNull = true
+		}
+	}
+
+	k := t.Kind()
+	switch {
+	case f.IsRaw:
+
+	case k == reflect.Chan, k == reflect.Func, k == reflect.Complex64, k == reflect.Complex128, k == reflect.UnsafePointer:
+		return &UnsupportedTypeError{Path: path, Type: t}
+
+	case k == reflect.Map:
+		var ok bool
+		if f.KeyType, ok = keyType(t.Key()); !ok {
+			return &UnsupportedTypeError{Path: path + " key", Type: t.Key()}
+		}
+		if s.opts.RecordMaps {
+			if keys := s.enumKeys(t.Key()); keys != "" {
+				f.KeyType = keys
 			}
-			tf.TsType = "object"
-			tf.ValType = s.addType(sft, "").Name
+		}
+		if f.Elem, err = s.elemField(t.Elem(), f.DateFormat, path+"[key]"); err != nil {
+			return
+		}
+		f.TsType, f.ValType = "map", f.Elem.Type(s.opts, true)
 
-		case k == reflect.Interface:
-			tf.TsType, tf.ValType = "object", ""
+	case k == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		f.TsType = "string" // encoding/json encodes []byte as a base64 string
 
-		case tf.TsType != "": // native type
-		default:
-			log.Println("unhandled", k, sft)
+	case k == reflect.Slice, k == reflect.Array:
+		if f.Elem, err = s.elemField(t.Elem(), f.DateFormat, path+"[]"); err != nil {
+			return
+		}
+		f.TsType, f.ValType = "array", f.Elem.Type(s.opts, true)
+		if k == reflect.Array {
+			f.TsType, f.Len = "tuple", t.Len()
 		}
 
-		out.Fields = append(out.Fields, &tf)
+	case k == reflect.Struct:
+		if f.IsDate {
+			break
+		}
+		f.TsType, f.ValType = "object", s.addType(t, "").Name
+
+	case k == reflect.Interface:
+		f.TsType, f.ValType = "object", ""
+
+	case f.TsType != "": // native type
+	default:
+		return &UnsupportedTypeError{Path: path, Type: t}
 	}
+
+	return
+}
+
+// elemField returns the Field describing the element type of an array or a map,
+// df is the date format of the container, which applies to its elements.
+func (s *StructToTS) elemField(t reflect.Type, df DateFormat, path string) (*Field, error) {
+	isPtr := t.Kind() == reflect.Ptr
+	t = indirect(t)
+	k := t.Kind()
+	f := &Field{
+		isPtr:      isPtr,
+		CanBeNull:  (isPtr || k == reflect.Slice || k == reflect.Map) && !isRaw(t), // encoding/json encodes nil elements as null
+		TsType:     stripType(t),
+		IsDate:     isDate(t) || df != DateDefault && !isContainer(t),
+		DateFormat: df,
+		IsRaw:      isRaw(t),
+	}
+	if err := s.setType(f, t, path); err != nil {
+		return nil, err
+	}
+	s.addAlias(t, f)
+	return f, nil
+}
+
+// addAlias records t if it's a named type that isn't a struct, like `type Status string`.
+func (s *StructToTS) addAlias(t reflect.Type, f *Field) {
+	if t.Name() == "" || t.PkgPath() == "" || t.Kind() == reflect.Struct || f.IsRaw || s.aliases[t] != nil {
+		return
+	}
+	s.aliases[t] = f
 }
 
 func (s *StructToTS) addType(t reflect.Type, name string) (out *Struct) {
@@ -142,95 +381,357 @@ func (s *StructToTS) addType(t reflect.Type, name string) (out *Struct) {
 		return out
 	}
 
+	named := name != "" || s.opts.Rename[typePath(t)] != ""
 	if name == "" {
-		name = t.Name()
-		if !s.opts.NoCapitalize {
-			name = capitalize(name)
-		}
+		name = s.typeName(t)
+	} else {
+		name = s.claimName(name, t)
 	}
 
 	out = &Struct{
 		Name:   name,
 		Fields: make([]*Field, 0, t.NumField()),
 		t:      t,
+		pkg:    t.PkgPath(),
+		goName: t.Name(),
+		named:  named,
 	}
 
 	s.seen[t] = out
 	// log.Println("building struct:", out.Name)
 	s.addTypeFields(out, t)
+	s.addComputed(out, t)
 	s.structs = append(s.structs, out)
 	// log.Println("/building struct:", out.Name)
 	return
 }
 
-func (s *StructToTS) RenderTo(w io.Writer) (err error) {
-	buf := bufio.NewWriter(w)
-	defer buf.Flush()
+// breakCycles makes pointer fields that are eagerly instantiated as part of a cycle nullable,
+// for example a `*Node` field tagged with `ts:",no-null"` inside Node,
+// otherwise the generated constructors would recurse forever.
+func (s *StructToTS) breakCycles() {
+	byName := structsByName(s.structs)
+	for {
+		cycle := findCycle(s.structs, byName)
+		if cycle == nil {
+			return
+		}
 
-	if s.opts.ES6 {
-		io.WriteString(w, "'use strict';\n")
+		broken := false
+		for _, e := range cycle {
+			if e.f.isPtr {
+				e.f.CanBeNull, broken = true, true
+			}
+		}
+
+		if !broken { // shouldn't be possible with valid Go types, but don't loop forever
+			e := cycle[len(cycle)-1]
+			e.f.CanBeNull = true
+		}
 	}
+}
 
-	if !s.opts.NoHelpers {
-		io.WriteString(w, "\n// helpers")
-		if s.opts.ES6 {
-			fmt.Fprint(w, es6_helpers)
-		} else {
-			fmt.Fprint(w, ts_helpers)
+type edge struct {
+	st *Struct
+	f  *Field // the struct field or the tuple element that's instantiated
+}
+
+// findCycle returns the edges of the first cycle of eagerly instantiated fields it finds, or nil.
+func findCycle(structs []*Struct, byName map[string]*Struct) []edge {
+	const (
+		visiting = iota + 1
+		done
+	)
+
+	var (
+		state = map[*Struct]int{}
+		path  []edge
+		visit func(st *Struct) []edge
+	)
+
+	visit = func(st *Struct) []edge {
+		state[st] = visiting
+		for _, f := range st.Fields {
+			ef := f.eager()
+			if ef == nil {
+				continue
+			}
+
+			next := byName[ef.ValType]
+			if next == nil {
+				continue
+			}
+
+			path = append(path, edge{st, ef})
+			switch state[next] {
+			case visiting:
+				for i, e := range path {
+					if e.st == next {
+						return path[i:]
+					}
+				}
+			case 0:
+				if c := visit(next); c != nil {
+					return c
+				}
+			}
+			path = path[:len(path)-1]
+		}
+		state[st] = done
+		return nil
+	}
+
+	for _, st := range structs {
+		if state[st] == 0 {
+			if c := visit(st); c != nil {
+				return c
+			}
 		}
-		io.WriteString(w, "\n")
 	}
 
-	io.WriteString(w, "// structs\n")
+	return nil
+}
+
+// typeName returns the TS name of t, applying Options.Rename and Options.OnNameCollision.
+// The names of colliding types are only tentative until resolveNames.
+func (s *StructToTS) typeName(t reflect.Type) string {
+	if name := s.opts.Rename[typePath(t)]; name != "" {
+		return s.claimName(name, t)
+	}
+
+	name := s.baseName(t)
+	if other, ok := s.names[name]; ok && other != t && s.opts.OnNameCollision != CollisionError {
+		name = s.disambiguate(s.names, name, t)
+	}
+
+	return s.claimName(name, t)
+}
+
+func (s *StructToTS) baseName(t reflect.Type) string {
+	if s.opts.NoCapitalize {
+		return t.Name()
+	}
+	return capitalize(t.Name())
+}
+
+// disambiguate returns the name of t for Options.OnNameCollision when another type already has name.
+func (s *StructToTS) disambiguate(names map[string]reflect.Type, name string, t reflect.Type) string {
+	if s.opts.OnNameCollision == CollisionPackage {
+		pkg := t.PkgPath()[strings.LastIndexByte(t.PkgPath(), '/')+1:]
+		if !s.opts.NoCapitalize {
+			pkg = capitalize(pkg)
+		}
+		if _, ok := names[pkg+name]; !ok {
+			return pkg + name
+		}
+		// same package name from a different path, fallback to the hash
+	}
+
+	h := fnv.New32a()
+	io.WriteString(h, t.PkgPath())
+	return fmt.Sprintf("%s_%08x", name, h.Sum32())
+}
+
+// claimName records name as t's and returns it, it records an error if another type already has it.
+func (s *StructToTS) claimName(name string, t reflect.Type) string {
+	if other, ok := s.names[name]; ok && other != t {
+		first, second := typePath(other), typePath(t)
+		if second < first {
+			first, second = second, first
+		}
+		s.errs = append(s.errs, &NameCollisionError{Name: name, First: first, Second: second})
+		return name
+	}
+	s.names[name] = t
+	return name
+}
+
+// resolveNames renames the colliding types by their package paths rather than the order they were added in:
+// the type with the first path keeps its name, so adding the same types in a different order doesn't change the output.
+func (s *StructToTS) resolveNames() {
+	if s.opts.OnNameCollision == CollisionError {
+		return
+	}
+
+	var (
+		names  = map[string]reflect.Type{}
+		groups = map[string][]*Struct{}
+		keys   []string
+	)
+
+	for _, st := range s.structs {
+		if st.named || st.t == nil {
+			names[st.Name] = st.t
+			continue
+		}
+		name := s.baseName(st.t)
+		if groups[name] == nil {
+			keys = append(keys, name)
+		}
+		groups[name] = append(groups[name], st)
+	}
+	sort.Strings(keys)
+
+	renames := map[string]string{}
+	for _, name := range keys {
+		sts := groups[name]
+		sort.Slice(sts, func(i, j int) bool { return typePath(sts[i].t) < typePath(sts[j].t) })
+		for _, st := range sts {
+			n := name
+			if _, ok := names[n]; ok {
+				n = s.disambiguate(names, n, st.t)
+			}
+			names[n] = st.t
+			if n != st.Name {
+				renames[st.Name], st.Name = n, n
+			}
+		}
+	}
+	s.names = names
+
+	if len(renames) == 0 {
+		return
+	}
+
+	seen := map[*Field]bool{}
 	for _, st := range s.structs {
-		if err = st.RenderTo(s.opts, buf); err != nil {
+		for _, f := range st.Fields {
+			s.renameField(f, renames, seen)
+		}
+		for _, c := range st.Computed {
+			s.renameField(c.Result, renames, seen)
+		}
+	}
+	for _, f := range s.aliases {
+		s.renameField(f, renames, seen)
+	}
+}
+
+// renameField updates the struct names f refers to, seen keeps fields that are shared from being renamed twice.
+func (s *StructToTS) renameField(f *Field, renames map[string]string, seen map[*Field]bool) {
+	if f == nil || seen[f] {
+		return
+	}
+	seen[f] = true
+
+	if f.Elem != nil {
+		s.renameField(f.Elem, renames, seen)
+		f.ValType = f.Elem.Type(s.opts, true)
+	} else if name, ok := renames[f.ValType]; ok && f.TsType == "object" {
+		f.ValType = name
+	}
+}
+
+// extendsFirst orders the structs so every class comes after the classes it extends,
+// since unlike type references, `extends` is evaluated when the class is defined.
+func extendsFirst(structs []*Struct) []*Struct {
+	var (
+		out  = make([]*Struct, 0, len(structs))
+		seen = map[*Struct]bool{}
+		add  func(st *Struct)
+	)
+
+	add = func(st *Struct) {
+		if seen[st] {
 			return
 		}
-		fmt.Fprint(buf, "\n\n")
+		seen[st] = true
+		for _, e := range st.Embeds {
+			add(e)
+		}
+		out = append(out, st)
 	}
 
-	if !s.opts.NoExports {
-		s.RenderExports(buf)
+	for _, st := range structs {
+		add(st)
 	}
 
-	return
+	return out
 }
 
-func (s *StructToTS) RenderExports(w io.Writer) (err error) {
-	if s.opts.InterfaceOnly && s.opts.NoHelpers {
-		// nothing else to export
-		return nil
+func (s *StructToTS) RenderTo(w io.Writer) (err error) {
+	if err = s.prepare(); err != nil {
+		return
 	}
 
-	io.WriteString(w, "// exports\n")
+	var (
+		r       = s.opts.renderer()
+		buf     = bufio.NewWriter(w)
+		structs bytes.Buffer
+	)
 
-	export := func(n string) { _, err = fmt.Fprintf(w, "%s%s,\n", s.opts.indents[1], n) }
-	if s.opts.ES6 {
-		fmt.Fprintf(w, "if (typeof exports === 'undefined') var exports = {};\n\n")
-		export = func(n string) { _, err = fmt.Fprintf(w, "exports.%s = %s;\n", n, n) }
-	} else {
-		io.WriteString(w, "export {\n")
+	// the structs are rendered first, the prologue only imports what their custom output uses
+	for _, st := range s.structs {
+		if err = st.render(r, &structs); err != nil {
+			return
+		}
+		structs.WriteString("\n\n")
 	}
 
-	// interfaces are exported inline!
-	if !s.opts.InterfaceOnly {
-		for _, st := range s.structs {
-			export(st.Name)
+	if err = r.RenderPrologue(buf, s.structs); err != nil {
+		return
+	}
+
+	structs.WriteTo(buf)
+
+	if err = r.RenderEpilogue(buf, s.structs); err != nil {
+		return
+	}
+
+	// bufio.Writer keeps the first error, so this catches any of the writes above failing.
+	return buf.Flush()
+}
+
+// prepare checks for errors and gets the structs ready to be rendered.
+func (s *StructToTS) prepare() error {
+	for _, e := range s.errs {
+		if _, ok := e.(*NameCollisionError); ok || s.opts.Strict {
+			return s.errs
 		}
 	}
 
-	if !s.opts.NoHelpers {
-		for _, n := range []string{"ParseDate", "ParseNumber", "FromArray", "ToObject"} {
-			export(n)
+	s.breakCycles()
+	s.structs = sortStructs(s.structs, s.opts.Order)
+
+	// the imports added by custom TS have to be known before the prologue is rendered
+	for _, st := range s.structs {
+		var buf strings.Builder
+		st.custom, st.imports, st.rendered = nil, nil, false
+		if err := st.renderCustom(&CustomContext{Struct: st, Options: s.opts, s: s}, &buf); err != nil {
+			return err
 		}
+		out := buf.String()
+		st.custom = &out
 	}
 
-	if !s.opts.ES6 {
-		io.WriteString(w, "};\n")
+	return nil
+}
+
+// imports returns the unique imports of the structs whose custom output was rendered, in order.
+func imports(structs []*Struct) (out []string) {
+	seen := map[string]bool{}
+	for _, st := range structs {
+		if !st.rendered {
+			continue
+		}
+		for _, imp := range st.imports {
+			if !seen[imp] {
+				seen[imp] = true
+				out = append(out, imp)
+			}
+		}
 	}
 	return
 }
 
+// RenderExports renders the exports of the structs and helpers, see Renderer.RenderEpilogue.
+func (s *StructToTS) RenderExports(w io.Writer) (err error) {
+	return s.opts.renderer().RenderEpilogue(w, s.structs)
+}
+
+// helperNames are the exported helpers.
+var helperNames = []string{"ParseDate", "ParseNullDate", "FormatDate", "ParseNumber", "FromArray", "ParseMap", "ParseObject", "DateEquals", "ArrayEquals", "MapEquals", "ToObject"}
+
 func indirect(t reflect.Type) reflect.Type {
 	k := t.Kind()
 	for k == reflect.Ptr {
@@ -242,7 +743,7 @@ func indirect(t reflect.Type) reflect.Type {
 
 func isNumber(k reflect.Kind) bool {
 	switch k {
-	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint,
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint, reflect.Uintptr,
 		reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int,
 		reflect.Float32, reflect.Float64:
 
@@ -275,6 +776,64 @@ func stripType(t reflect.Type) string {
 	return n
 }
 
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// keyType returns the TS type of a map key, following the encoding/json rules:
+// string, integer and encoding.TextMarshaler keys are all encoded as strings, anything else is unsupported.
+func keyType(t reflect.Type) (_ string, ok bool) {
+	switch k := t.Kind(); {
+	case k == reflect.String, isNumber(k) && k != reflect.Float32 && k != reflect.Float64:
+	case t.Implements(textMarshalerType): // encoding/json doesn't check *T
+	default:
+		return "", false
+	}
+	return "string", true
+}
+
+// enumKeys returns the union of the wire values of the constants declared for the named map key type t
+// (`'active' | 'disabled'`, integers are strings on the wire), or an empty string if it doesn't have any.
+func (s *StructToTS) enumKeys(t reflect.Type) string {
+	if t.Name() == "" || t.PkgPath() == "" || t.Implements(textMarshalerType) {
+		return ""
+	}
+
+	if k := t.Kind(); k != reflect.String && !isNumber(k) {
+		return ""
+	}
+
+	var (
+		keys []string
+		seen = map[string]bool{}
+	)
+
+	for _, c := range s.pkgs.get(t.PkgPath()).consts(t.Name()) {
+		var key string
+		switch v := c.Val(); v.Kind() {
+		case constant.String:
+			key = constant.StringVal(v)
+		case constant.Int:
+			key = v.ExactString()
+		default:
+			continue
+		}
+
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, jsString(key))
+		}
+	}
+
+	return strings.Join(keys, " | ")
+}
+
+// typePath returns the full Go path of t, for example `github.com/you/users.User`.
+func typePath(t reflect.Type) string {
+	if t.Name() == "" {
+		return t.String()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
+
 func capitalize(s string) string {
 	var last rune
 	return strings.Map(func(r rune) rune {
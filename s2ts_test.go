@@ -1,11 +1,20 @@
 package struct2ts_test
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"strings"
+	"testing"
 	"time"
 
 	"github.com/OneOfOne/struct2ts"
+	"github.com/OneOfOne/struct2ts/testdata/testmodel1"
+	"github.com/OneOfOne/struct2ts/testdata/testmodel2"
 )
 
 type OtherStruct struct {
@@ -35,72 +44,158 @@ func ExampleComplexStruct() {
 	// Output:
 	// // helpers
 	// const maxUnixTSInSeconds = 9999999999;
+	// const goZeroTime = -62135596800000; // 0001-01-01T00:00:00Z
 	//
-	// function ParseDate(d: Date | number | string): Date {
+	// function ParseDate(d: Date | number | string, format = ''): Date {
 	// 	if (d instanceof Date) return d;
 	// 	if (typeof d === 'number') {
+	// 		switch (format) {
+	// 			case 'unix': return new Date(d * 1000);
+	// 			case 'unixms': return new Date(d);
+	// 			case 'unixnano': return new Date(d / 1e6);
+	// 		}
 	// 		if (d > maxUnixTSInSeconds) return new Date(d);
 	// 		return new Date(d * 1000); // go ts
 	// 	}
 	// 	return new Date(d);
 	// }
 	//
+	// function ParseNullDate<Z extends null | undefined>(d: Date | number | string | null | undefined, format: string, zero: Z): Date | Z {
+	// 	if (d == null || d === 0) return zero;
+	// 	const t = ParseDate(d, format);
+	// 	return t.getTime() === goZeroTime ? zero : t;
+	// }
+	//
+	// function FormatDate(d: Date | null, format = ''): number | string {
+	// 	if (!d) return format === 'string' ? '0001-01-01T00:00:00Z' : 0;
+	// 	switch (format) {
+	// 		case 'string': return d.toISOString().replace(/\.?0+Z$/, 'Z'); // trim the zero ms like Go
+	// 		case 'unixms': return d.getTime();
+	// 		case 'unixnano': return d.getTime() * 1e6;
+	// 	}
+	// 	return Math.floor(d.getTime() / 1000);
+	// }
+	//
 	// function ParseNumber(v: number | string, isInt = false): number {
 	// 	if (!v) return 0;
 	// 	if (typeof v === 'number') return v;
 	// 	return (isInt ? parseInt(v) : parseFloat(v)) || 0;
 	// }
 	//
-	// function FromArray<T>(Ctor: { new(v: any): T }, data?: any[] | any, def = null): T[] | null {
+	// function FromArray<T>(Ctor: { new (v: any): T }, data?: any[] | any, def = null): T[] | null {
 	// 	if (!data || !Object.keys(data).length) return def;
 	// 	const d = Array.isArray(data) ? data : [data];
 	// 	return d.map((v: any) => new Ctor(v));
 	// }
 	//
+	// function ParseMap<T>(data: any, conv: (v: any) => T, def: { [key: string]: T } | null = null): { [key: string]: T } | null {
+	// 	if (!data || typeof data !== 'object') return def;
+	// 	const m: { [key: string]: T } = {};
+	// 	for (const k of Object.keys(data)) m[k] = conv(data[k]);
+	// 	return m;
+	// }
+	//
+	// // ParseObject copies the constructor input, class instances are copied like plain objects
+	// // so their Dates are kept as is and every field parses them in its own format.
+	// // Nulls are kept as well, only missing and undefined fields get their default.
+	// function ParseObject(o: any): any {
+	// 	if (o == null || typeof o !== 'object' || o instanceof Date) return o;
+	// 	if (Array.isArray(o)) return o.map(ParseObject);
+	// 	const d: any = {};
+	// 	for (const k of Object.keys(o)) if (o[k] !== undefined) d[k] = ParseObject(o[k]);
+	// 	return d;
+	// }
+	//
+	// function DateEquals(a: Date | null, b: Date | null): boolean {
+	// 	if (a === b) return true;
+	// 	if (!a || !b) return false;
+	// 	return a.getTime() === b.getTime();
+	// }
+	//
+	// function ArrayEquals<T>(a: ReadonlyArray<T> | null, b: ReadonlyArray<T> | null, eq = (x: T, y: T) => x === y): boolean {
+	// 	if (a === b) return true;
+	// 	if (!a || !b || a.length !== b.length) return false;
+	// 	for (let i = 0; i < a.length; i++) if (!eq(a[i], b[i])) return false;
+	// 	return true;
+	// }
+	//
+	// function MapEquals<T>(a: { [key: string]: T } | null, b: { [key: string]: T } | null, eq = (x: T, y: T) => x === y): boolean {
+	// 	if (a === b) return true;
+	// 	if (!a || !b) return false;
+	// 	const keys = Object.keys(a);
+	// 	if (keys.length !== Object.keys(b).length) return false;
+	// 	for (const k of keys) if (!(k in b) || !eq(a[k], b[k])) return false;
+	// 	return true;
+	// }
+	//
 	// function ToObject(o: any, typeOrCfg: any = {}, child = false): any {
-	// 	if (!o) return null;
+	// 	const [type, ...flags] = typeof typeOrCfg === 'string' ? typeOrCfg.split(',') : [''];
+	// 	if (o == null) return flags.indexOf('zero') > -1 ? FormatDate(null, type) : null;
 	// 	if (typeof o.toObject === 'function' && child) return o.toObject();
 	//
 	// 	switch (typeof o) {
 	// 		case 'string':
-	// 			return typeOrCfg === 'number' ? ParseNumber(o) : o;
+	// 			return type === 'number' ? ParseNumber(o) : o;
 	// 		case 'boolean':
 	// 		case 'number':
-	// 			return o;
+	// 			return type === 'string' ? String(o) : o; // the string json option
 	// 	}
 	//
-	// 	if (o instanceof Date) {
-	// 		return typeOrCfg === 'string' ? o.toISOString() : Math.floor(o.getTime() / 1000);
-	// 	}
+	// 	if (o instanceof Date) return FormatDate(o, type);
 	//
-	// 	if (Array.isArray(o)) return o.map((v: any) => ToObject(v, typeOrCfg, true));
+	// 	if (Array.isArray(o)) return o.map((v: any) => ToObject(v, type, true));
 	//
 	// 	const d: any = {};
+	// 	const isMap = typeof typeOrCfg === 'string'; // only struct fields are omitted, map elements are kept even if null
 	//
 	// 	for (const k of Object.keys(o)) {
-	// 		const v: any = o[k];
-	// 		if (!v) continue;
-	// 		d[k] = ToObject(v, typeOrCfg[k] || {}, true);
+	// 		const cfg: any = isMap ? type : typeOrCfg[k] || '';
+	// 		if (o[k] === undefined && typeof cfg === 'string' && cfg.split(',').indexOf('optional') > -1) continue; // nil embedded pointer
+	// 		const v: any = typeof cfg === 'function' ? cfg(o[k]) : ToObject(o[k], cfg, true);
+	// 		if (isMap || !IsOmitted(v, cfg)) d[k] = v;
 	// 	}
 	//
 	// 	return d;
 	// }
 	//
-	// // classes
-	// // struct2ts:github.com/OneOfOne/struct2ts_test.ComplexStructOtherStruct
-	// class ComplexStructOtherStruct {
+	// // IsOmitted reports whether encoding/json would omit a field with the serialized value v.
+	// function IsOmitted(v: any, cfg: any): boolean {
+	// 	if (typeof cfg === 'function') return v === undefined; // custom conversion, like JSON.stringify
+	// 	const flags = cfg.split(',');
+	// 	if (v == null) return flags.indexOf('null') === -1;
+	// 	if (flags[0] === 'string' && (v === '0' || v === 'false')) v = 0; // the string json option, omitempty and omitzero check the value
+	// 	if (flags.indexOf('omitempty') > -1) return typeof v === 'object' ? !Object.keys(v).length : !v;
+	// 	if (flags.indexOf('omitzero') > -1) return !v || v === '0001-01-01T00:00:00Z';
+	// 	return false;
+	// }
+	//
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.OtherStruct
+	// class OtherStruct {
 	// 	t: Date;
 	//
 	// 	constructor(data?: any) {
-	// 		const d: any = (data && typeof data === 'object') ? ToObject(data) : {};
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
 	// 		this.t = ('t' in d) ? ParseDate(d.t) : new Date();
 	// 	}
 	//
+	// 	static fromJSON(json: string): OtherStruct {
+	// 		return new OtherStruct(JSON.parse(json));
+	// 	}
+	//
+	// 	static fromArray(data?: any[] | any): OtherStruct[] | null {
+	// 		return FromArray(OtherStruct, data);
+	// 	}
+	//
 	// 	toObject(): any {
 	// 		const cfg: any = {};
 	// 		cfg.t = 'string';
 	// 		return ToObject(this, cfg);
 	// 	}
+	//
+	// 	toJSON(): any {
+	// 		return this.toObject();
+	// 	}
 	// }
 	//
 	// // struct2ts:github.com/OneOfOne/struct2ts_test.ComplexStruct
@@ -110,40 +205,1554 @@ func ExampleComplexStruct() {
 	// 	f: number;
 	// 	ts: Date | null;
 	// 	t: Date;
-	// 	o: ComplexStructOtherStruct | null;
-	// 	nno: ComplexStructOtherStruct;
-	// 	d: { [key: string]: any };
+	// 	o: OtherStruct | null;
+	// 	nno: OtherStruct;
+	// 	d: { [key: string]: any } | null;
 	// 	dp: { [key: string]: any } | null;
+	// 	rm: any;
 	//
 	// 	constructor(data?: any) {
-	// 		const d: any = (data && typeof data === 'object') ? ToObject(data) : {};
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
 	// 		this.s = ('s' in d) ? d.s as string : '';
 	// 		this.i = ('i' in d) ? d.i as number : 0;
 	// 		this.f = ('f' in d) ? d.f as number : 0;
-	// 		this.ts = ('ts' in d) ? ParseDate(d.ts) : null;
+	// 		this.ts = ('ts' in d) ? (d.ts == null ? null : ParseDate(d.ts)) : null;
 	// 		this.t = ('t' in d) ? ParseDate(d.t) : new Date();
-	// 		this.o = ('o' in d) ? new ComplexStructOtherStruct(d.o) : null;
-	// 		this.nno = new ComplexStructOtherStruct(d.nno);
-	// 		this.d = ('d' in d) ? d.d as { [key: string]: any } : {};
+	// 		this.o = (d.o != null) ? new OtherStruct(d.o) : null;
+	// 		this.nno = new OtherStruct(d.nno);
+	// 		this.d = ('d' in d) ? d.d as { [key: string]: any } : null;
 	// 		this.dp = ('dp' in d) ? d.dp as { [key: string]: any } : null;
+	// 		this.rm = ('rm' in d) ? d.rm as any : null;
+	// 	}
+	//
+	// 	static fromJSON(json: string): ComplexStruct {
+	// 		return new ComplexStruct(JSON.parse(json));
+	// 	}
+	//
+	// 	static fromArray(data?: any[] | any): ComplexStruct[] | null {
+	// 		return FromArray(ComplexStruct, data);
 	// 	}
 	//
 	// 	toObject(): any {
 	// 		const cfg: any = {};
-	// 		cfg.i = 'number';
-	// 		cfg.f = 'number';
+	// 		cfg.s = ',omitempty';
+	// 		cfg.i = 'number,omitempty';
+	// 		cfg.f = 'number,omitempty';
 	// 		cfg.t = 'string';
+	// 		cfg.d = ',null';
+	// 		cfg.dp = ',null';
+	// 		cfg.rm = ',null';
 	// 		return ToObject(this, cfg);
 	// 	}
+	//
+	// 	toJSON(): any {
+	// 		return this.toObject();
+	// 	}
 	// }
 	//
 	// // exports
 	// export {
-	// 	ComplexStructOtherStruct,
+	// 	OtherStruct,
 	// 	ComplexStruct,
 	// 	ParseDate,
+	// 	ParseNullDate,
+	// 	FormatDate,
 	// 	ParseNumber,
 	// 	FromArray,
+	// 	ParseMap,
+	// 	ParseObject,
+	// 	DateEquals,
+	// 	ArrayEquals,
+	// 	MapEquals,
 	// 	ToObject,
 	// };
 }
+
+type TupleStruct struct {
+	Point  [3]float64     `json:"point"`
+	Names  [2]string      `json:"names"`
+	Others [2]OtherStruct `json:"others"`
+}
+
+func Example_tuples() {
+	s2ts := struct2ts.New(&struct2ts.Options{NoHelpers: true, NoFactories: true, NoExports: true, NoToObject: true})
+	s2ts.Add(TupleStruct{})
+	s2ts.RenderTo(os.Stdout)
+
+	// Output:
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.OtherStruct
+	// class OtherStruct {
+	// 	t: Date;
+	//
+	// 	constructor(data?: any) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.t = ('t' in d) ? ParseDate(d.t) : new Date();
+	// 	}
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.TupleStruct
+	// class TupleStruct {
+	// 	point: [number, number, number];
+	// 	names: [string, string];
+	// 	others: [OtherStruct, OtherStruct];
+	//
+	// 	constructor(data?: any) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.point = (Array.isArray(d.point) && d.point.length === 3) ? d.point as [number, number, number] : [0, 0, 0];
+	// 		this.names = (Array.isArray(d.names) && d.names.length === 2) ? d.names as [string, string] : ['', ''];
+	// 		this.others = (Array.isArray(d.others) && d.others.length === 2) ? d.others.map((v: any) => new OtherStruct(v)) as [OtherStruct, OtherStruct] : [new OtherStruct(), new OtherStruct()];
+	// 	}
+	// }
+}
+
+type RawStruct struct {
+	Raw    json.RawMessage             `json:"raw"`
+	Ptr    *json.RawMessage            `json:"ptr"`
+	Pair   [2]json.RawMessage          `json:"pair"`
+	Ptrs   []*json.RawMessage          `json:"ptrs"`
+	ByName map[string]*json.RawMessage `json:"byName"`
+}
+
+func Example_rawMessage() {
+	s2ts := struct2ts.New(&struct2ts.Options{InterfaceOnly: true, NoHelpers: true, NoExports: true})
+	s2ts.Add(RawStruct{})
+	s2ts.RenderTo(os.Stdout)
+
+	// Output:
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.RawStruct
+	// interface RawStruct {
+	// 	raw: any;
+	// 	ptr: any;
+	// 	pair: [any, any];
+	// 	ptrs: any[] | null;
+	// 	byName: { [key: string]: any } | null;
+	// }
+}
+
+type NestedStruct struct {
+	Matrix   [][]float64                `json:"matrix"`
+	Others   [][]*OtherStruct           `json:"others"`
+	ByName   map[string][]OtherStruct   `json:"byName"`
+	Maps     []map[string]int           `json:"maps"`
+	Times    []time.Time                `json:"times"`
+	Any      []interface{}              `json:"any"`
+	Bytes    []byte                     `json:"bytes"`
+	Deep     [][2][]OtherStruct         `json:"deep"`
+	MapOfMap map[string]map[string]bool `json:"mapOfMap"`
+	Ptrs     map[string]*OtherStruct    `json:"ptrs"`
+	TimeMap  map[string]time.Time       `json:"timeMap"`
+}
+
+func Example_nested() {
+	s2ts := struct2ts.New(&struct2ts.Options{NoHelpers: true, NoFactories: true, NoExports: true})
+	s2ts.Add(NestedStruct{})
+	s2ts.RenderTo(os.Stdout)
+
+	// Output:
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.OtherStruct
+	// class OtherStruct {
+	// 	t: Date;
+	//
+	// 	constructor(data?: any) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.t = ('t' in d) ? ParseDate(d.t) : new Date();
+	// 	}
+	//
+	// 	toObject(): any {
+	// 		const cfg: any = {};
+	// 		cfg.t = 'string';
+	// 		return ToObject(this, cfg);
+	// 	}
+	//
+	// 	toJSON(): any {
+	// 		return this.toObject();
+	// 	}
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.NestedStruct
+	// class NestedStruct {
+	// 	matrix: (number[] | null)[] | null;
+	// 	others: ((OtherStruct | null)[] | null)[] | null;
+	// 	byName: { [key: string]: OtherStruct[] | null } | null;
+	// 	maps: ({ [key: string]: number } | null)[] | null;
+	// 	times: Date[] | null;
+	// 	any: any[] | null;
+	// 	bytes: string | null;
+	// 	deep: [OtherStruct[] | null, OtherStruct[] | null][] | null;
+	// 	mapOfMap: { [key: string]: { [key: string]: boolean } | null } | null;
+	// 	ptrs: { [key: string]: OtherStruct | null } | null;
+	// 	timeMap: { [key: string]: Date } | null;
+	//
+	// 	constructor(data?: any) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.matrix = ('matrix' in d) ? d.matrix as (number[] | null)[] : null;
+	// 		this.others = Array.isArray(d.others) ? d.others.map((v: any) => (Array.isArray(v) ? v.map((v1: any) => (v1 == null ? null : new OtherStruct(v1))) : null)) : null;
+	// 		this.byName = ParseMap(d.byName, (v: any) => (Array.isArray(v) ? v.map((v1: any) => new OtherStruct(v1)) : null), null) as { [key: string]: OtherStruct[] | null };
+	// 		this.maps = ('maps' in d) ? d.maps as ({ [key: string]: number } | null)[] : null;
+	// 		this.times = Array.isArray(d.times) ? d.times.map((v: any) => ParseDate(v)) : null;
+	// 		this.any = ('any' in d) ? d.any as any[] : null;
+	// 		this.bytes = ('bytes' in d) ? d.bytes as string : null;
+	// 		this.deep = Array.isArray(d.deep) ? d.deep.map((v: any) => ((Array.isArray(v) && v.length === 2) ? v.map((v1: any) => (Array.isArray(v1) ? v1.map((v2: any) => new OtherStruct(v2)) : null)) as [OtherStruct[] | null, OtherStruct[] | null] : [null, null])) : null;
+	// 		this.mapOfMap = ('mapOfMap' in d) ? d.mapOfMap as { [key: string]: { [key: string]: boolean } | null } : null;
+	// 		this.ptrs = ParseMap(d.ptrs, (v: any) => (v == null ? null : new OtherStruct(v)), null) as { [key: string]: OtherStruct | null };
+	// 		this.timeMap = ParseMap(d.timeMap, (v: any) => ParseDate(v), null) as { [key: string]: Date };
+	// 	}
+	//
+	// 	toObject(): any {
+	// 		const cfg: any = {};
+	// 		cfg.matrix = 'number,null';
+	// 		cfg.others = ',null';
+	// 		cfg.byName = ',null';
+	// 		cfg.maps = 'number,null';
+	// 		cfg.times = 'string,null';
+	// 		cfg.any = ',null';
+	// 		cfg.bytes = ',null';
+	// 		cfg.deep = ',null';
+	// 		cfg.mapOfMap = ',null';
+	// 		cfg.ptrs = ',null';
+	// 		cfg.timeMap = 'string,null';
+	// 		return ToObject(this, cfg);
+	// 	}
+	//
+	// 	toJSON(): any {
+	// 		return this.toObject();
+	// 	}
+	// }
+}
+
+type Point struct{ X, Y int }
+
+func (p Point) MarshalText() ([]byte, error) { return []byte(fmt.Sprintf("%d,%d", p.X, p.Y)), nil }
+
+type MapKeysStruct struct {
+	ByID     map[int64]string         `json:"byID"`
+	ByPoint  map[Point]bool           `json:"byPoint"`
+	ByStatus map[Status]int           `json:"byStatus"`
+	ByLevel  map[Level]string         `json:"byLevel"`
+	Counts   map[Status][]OtherStruct `json:"counts"`
+}
+
+func Example_mapKeys() {
+	s2ts := struct2ts.New(&struct2ts.Options{NoHelpers: true, NoExports: true, NoConstructor: true, NoToObject: true, RecordMaps: true})
+	s2ts.Add(MapKeysStruct{})
+	s2ts.RenderTo(os.Stdout)
+
+	// Output:
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.OtherStruct
+	// class OtherStruct {
+	// 	t: Date = new Date();
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.MapKeysStruct
+	// class MapKeysStruct {
+	// 	byID: Record<string, string> | null = null;
+	// 	byPoint: Record<string, boolean> | null = null;
+	// 	byStatus: Partial<Record<'active' | 'banned', number>> | null = null;
+	// 	byLevel: Partial<Record<'1' | '2', string>> | null = null;
+	// 	counts: Partial<Record<'active' | 'banned', OtherStruct[] | null>> | null = null;
+	// }
+}
+
+type Node struct {
+	Value    int      `json:"value"`
+	Parent   *Node    `json:"parent" ts:",no-null"`
+	Children []*Node  `json:"children"`
+	Pair     [2]*Node `json:"pair"`
+}
+
+func Example_recursive() {
+	s2ts := struct2ts.New(&struct2ts.Options{NoHelpers: true, NoFactories: true, NoExports: true, NoToObject: true})
+	s2ts.Add(Node{})
+	s2ts.RenderTo(os.Stdout)
+
+	// Output:
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Node
+	// class Node {
+	// 	value: number;
+	// 	parent: Node | null;
+	// 	children: (Node | null)[] | null;
+	// 	pair: [Node | null, Node | null];
+	//
+	// 	constructor(data?: any) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.value = ('value' in d) ? d.value as number : 0;
+	// 		this.parent = (d.parent != null) ? new Node(d.parent) : null;
+	// 		this.children = Array.isArray(d.children) ? d.children.map((v: any) => (v == null ? null : new Node(v))) : null;
+	// 		this.pair = (Array.isArray(d.pair) && d.pair.length === 2) ? d.pair.map((v: any) => (v == null ? null : new Node(v))) as [Node | null, Node | null] : [null, null];
+	// 	}
+	// }
+}
+
+type Base struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type audit struct {
+	CreatedBy string `json:"createdBy"`
+	Name      string `json:"name"` // conflicts with Base.Name, but both are hidden by Embedded.Name
+}
+
+type Embedded struct {
+	*Base
+	audit
+
+	Name  string `json:"name"` // shadows Base.Name
+	Other Base   `json:"other"`
+	Inner Base   `json:"inner"`
+}
+
+type Tagged struct {
+	Base `json:"base"`
+}
+
+type Profile struct {
+	Base
+	Bio string `json:"bio"`
+}
+
+type Shadowed struct {
+	Base
+	Name int `json:"name"` // can't be extended, Name would have two different types
+}
+
+func TestEmbeddedFieldsMatchJSON(t *testing.T) {
+	for _, v := range []interface{}{
+		Embedded{Base: &Base{}},
+		Tagged{},
+		Shadowed{},
+		Customer{}, // json:",inline" isn't flattened
+	} {
+		j, _ := json.Marshal(v)
+		var m map[string]interface{}
+		json.Unmarshal(j, &m)
+
+		st := struct2ts.New(nil).Add(v)
+		if len(st.Fields) != len(m) {
+			t.Fatalf("%T: expected %d fields (%s), got %d", v, len(m), j, len(st.Fields))
+		}
+		for _, f := range st.Fields {
+			if _, ok := m[f.Name]; !ok {
+				t.Fatalf("%T: unexpected field %q (%s)", v, f.Name, j)
+			}
+		}
+	}
+}
+
+func Example_embedded() {
+	s2ts := struct2ts.New(&struct2ts.Options{InterfaceOnly: true, ExtendEmbedded: true, NoHelpers: true, NoExports: true})
+	s2ts.Add(Embedded{})
+	s2ts.Add(Tagged{})
+	s2ts.Add(Profile{})
+	s2ts.Add(Shadowed{})
+	s2ts.RenderTo(os.Stdout)
+
+	// Output:
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Base
+	// interface Base {
+	// 	id: number;
+	// 	name: string;
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Embedded
+	// interface Embedded {
+	// 	id?: number | undefined;
+	// 	createdBy: string;
+	// 	name: string;
+	// 	other: Base;
+	// 	inner: Base;
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Tagged
+	// interface Tagged {
+	// 	base: Base;
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Profile
+	// interface Profile extends Base {
+	// 	bio: string;
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Shadowed
+	// interface Shadowed {
+	// 	id: number;
+	// 	name: number;
+	// }
+}
+
+type Model struct {
+	ID      int       `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type User struct {
+	Model
+	Name string `json:"name"`
+}
+
+func Example_inheritance() {
+	s2ts := struct2ts.New(&struct2ts.Options{ExtendEmbedded: true, NoHelpers: true, NoExports: true})
+	s2ts.Add(User{})
+	s2ts.Add(Embedded{}) // embeds more than one struct, so it's flattened
+	s2ts.RenderTo(os.Stdout)
+
+	// Output:
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Model
+	// class Model {
+	// 	id: number;
+	// 	created: Date;
+	//
+	// 	constructor(data?: any) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.id = ('id' in d) ? d.id as number : 0;
+	// 		this.created = ('created' in d) ? ParseDate(d.created) : new Date();
+	// 	}
+	//
+	// 	static fromJSON(json: string): Model {
+	// 		return new Model(JSON.parse(json));
+	// 	}
+	//
+	// 	static fromArray(data?: any[] | any): Model[] | null {
+	// 		return FromArray(Model, data);
+	// 	}
+	//
+	// 	toObject(): any {
+	// 		const cfg: any = {};
+	// 		cfg.id = 'number';
+	// 		cfg.created = 'string';
+	// 		return ToObject(this, cfg);
+	// 	}
+	//
+	// 	toJSON(): any {
+	// 		return this.toObject();
+	// 	}
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.User
+	// class User extends Model {
+	// 	name: string;
+	//
+	// 	constructor(data?: any) {
+	// 		super(data);
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.name = ('name' in d) ? d.name as string : '';
+	// 	}
+	//
+	// 	static fromJSON(json: string): User {
+	// 		return new User(JSON.parse(json));
+	// 	}
+	//
+	// 	static fromArray(data?: any[] | any): User[] | null {
+	// 		return FromArray(User, data);
+	// 	}
+	//
+	// 	toObject(): any {
+	// 		const cfg: any = {};
+	// 		cfg.id = 'number';
+	// 		cfg.created = 'string';
+	// 		return ToObject(this, cfg);
+	// 	}
+	//
+	// 	toJSON(): any {
+	// 		return this.toObject();
+	// 	}
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Base
+	// class Base {
+	// 	id: number;
+	// 	name: string;
+	//
+	// 	constructor(data?: any) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.id = ('id' in d) ? d.id as number : 0;
+	// 		this.name = ('name' in d) ? d.name as string : '';
+	// 	}
+	//
+	// 	static fromJSON(json: string): Base {
+	// 		return new Base(JSON.parse(json));
+	// 	}
+	//
+	// 	static fromArray(data?: any[] | any): Base[] | null {
+	// 		return FromArray(Base, data);
+	// 	}
+	//
+	// 	toObject(): any {
+	// 		const cfg: any = {};
+	// 		cfg.id = 'number';
+	// 		return ToObject(this, cfg);
+	// 	}
+	//
+	// 	toJSON(): any {
+	// 		return this.toObject();
+	// 	}
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Embedded
+	// class Embedded {
+	// 	id?: number | undefined;
+	// 	createdBy: string;
+	// 	name: string;
+	// 	other: Base;
+	// 	inner: Base;
+	//
+	// 	constructor(data?: any) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.id = ('id' in d) ? d.id as number : undefined;
+	// 		this.createdBy = ('createdBy' in d) ? d.createdBy as string : '';
+	// 		this.name = ('name' in d) ? d.name as string : '';
+	// 		this.other = new Base(d.other);
+	// 		this.inner = new Base(d.inner);
+	// 	}
+	//
+	// 	static fromJSON(json: string): Embedded {
+	// 		return new Embedded(JSON.parse(json));
+	// 	}
+	//
+	// 	static fromArray(data?: any[] | any): Embedded[] | null {
+	// 		return FromArray(Embedded, data);
+	// 	}
+	//
+	// 	toObject(): any {
+	// 		const cfg: any = {};
+	// 		cfg.id = 'number,optional';
+	// 		return ToObject(this, cfg);
+	// 	}
+	//
+	// 	toJSON(): any {
+	// 		return this.toObject();
+	// 	}
+	// }
+}
+
+// PtrPoint implements encoding.TextMarshaler on its pointer, which encoding/json doesn't accept for map keys.
+type PtrPoint struct{ X, Y int }
+
+func (p *PtrPoint) MarshalText() ([]byte, error) { return []byte(fmt.Sprintf("%d,%d", p.X, p.Y)), nil }
+
+type Unsupported struct {
+	Name       string                `json:"name"`
+	Callback   func()                `json:"-"`
+	Events     chan int              `json:"events"`
+	Handlers   []func() error        `json:"handlers"`
+	Values     map[string]complex128 `json:"values"`
+	ByFloat    map[float64]string    `json:"byFloat"`
+	ByPtrPoint map[PtrPoint]string   `json:"byPtrPoint"`
+}
+
+func TestUnsupportedFields(t *testing.T) {
+	s := struct2ts.New(nil)
+	st := s.Add(Unsupported{})
+	if len(st.Fields) != 1 || st.Fields[0].Name != "name" {
+		t.Fatalf("unexpected fields: %+v", st.Fields)
+	}
+
+	errs, ok := s.Err().(struct2ts.Errors)
+	if !ok || len(errs) != 5 {
+		t.Fatalf("expected 5 errors, got %v", s.Err())
+	}
+
+	const path = "github.com/OneOfOne/struct2ts_test.Unsupported."
+	for i, exp := range []string{"Events", "Handlers[]", "Values[key]", "ByFloat key", "ByPtrPoint key"} {
+		if e := errs[i].(*struct2ts.UnsupportedTypeError); e.Path != path+exp {
+			t.Fatalf("expected %s, got %s", path+exp, e.Path)
+		}
+	}
+
+	strict := struct2ts.New(&struct2ts.Options{Strict: true})
+	strict.Add(Unsupported{})
+	if err := strict.RenderTo(ioutil.Discard); err == nil {
+		t.Fatal("expected an error in strict mode")
+	}
+}
+
+func TestStringOption(t *testing.T) {
+	s := struct2ts.New(nil)
+	st := s.Add(struct {
+		A int64  `json:"a,string"`
+		B bool   `json:"b,string"`
+		C string `json:"c,string"`
+		D []int  `json:"d,string"` // ignored by encoding/json
+	}{})
+	if len(st.Fields) != 4 {
+		t.Fatalf("unexpected fields: %+v", st.Fields)
+	}
+
+	// strings are kept quoted
+	errs, ok := s.Err().(struct2ts.Errors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", s.Err())
+	}
+	if e, ok := errs[0].(*struct2ts.UnsupportedOptionError); !ok || !strings.HasSuffix(e.Path, ".C") || e.Option != "string" {
+		t.Fatalf("unexpected error: %v", errs[0])
+	}
+}
+
+type failWriter int
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if *w -= failWriter(len(p)); *w < 0 {
+		return 0, errors.New("write failed")
+	}
+	return len(p), nil
+}
+
+func TestRenderWriteErrors(t *testing.T) {
+	s := struct2ts.New(nil)
+	s.Add(ComplexStruct{})
+
+	var buf bytes.Buffer
+	if err := s.RenderTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range []int{0, buf.Len() / 2, buf.Len() - 1} {
+		w := failWriter(n)
+		if err := s.RenderTo(&w); err == nil {
+			t.Fatalf("expected an error after %d bytes", n)
+		}
+	}
+
+	st := s.Add(ComplexStruct{})
+	w := failWriter(100)
+	if err := st.RenderTo(&struct2ts.Options{}, &w); err == nil {
+		t.Fatal("expected an error from Struct.RenderTo")
+	}
+}
+
+func TestNameCollisions(t *testing.T) {
+	s := struct2ts.New(nil)
+	s.Add(testmodel2.Struct1{})
+	err := s.RenderTo(ioutil.Discard)
+	if errs, ok := err.(struct2ts.Errors); !ok || len(errs) != 1 {
+		t.Fatalf("expected a collision error, got %v", err)
+	}
+	if err.Error() != "github.com/OneOfOne/struct2ts/testdata/testmodel1.Struct1 and github.com/OneOfOne/struct2ts/testdata/testmodel2.Struct1 are both named Struct1" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		opts  struct2ts.Options
+		names []string
+	}{
+		{struct2ts.Options{OnNameCollision: struct2ts.CollisionPackage}, []string{"Struct1", "Testmodel2Struct1"}},
+		{struct2ts.Options{OnNameCollision: struct2ts.CollisionHash}, []string{"Struct1", "Struct1_75451e91"}},
+		{struct2ts.Options{Rename: map[string]string{
+			"github.com/OneOfOne/struct2ts/testdata/testmodel1.Struct1": "Model1",
+			"github.com/OneOfOne/struct2ts/testdata/testmodel2.Struct1": "Model2",
+		}}, []string{"Model1", "Model2"}},
+	} {
+		s := struct2ts.New(&tc.opts)
+		st := s.Add(testmodel2.Struct1{})
+		if err := s.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if names := []string{st.Fields[0].ValType, st.Name}; names[0] != tc.names[0] || names[1] != tc.names[1] {
+			t.Fatalf("expected %v, got %v", tc.names, names)
+		}
+
+		// the names don't depend on the order the types are added in
+		s = struct2ts.New(&tc.opts)
+		s1 := s.Add(testmodel1.Struct1{})
+		s2 := s.Add(testmodel2.Struct1{})
+		if names := []string{s1.Name, s2.Name}; names[0] != tc.names[0] || names[1] != tc.names[1] {
+			t.Fatalf("expected %v, got %v", tc.names, names)
+		}
+	}
+}
+
+func TestOrder(t *testing.T) {
+	classes := func(order struct2ts.Order, vs ...interface{}) (out []string) {
+		s := struct2ts.New(&struct2ts.Options{Order: order, NoHelpers: true, NoExports: true, ExtendEmbedded: true})
+		for _, v := range vs {
+			s.Add(v)
+		}
+
+		var buf bytes.Buffer
+		if err := s.RenderTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, l := range strings.Split(buf.String(), "\n") {
+			if strings.HasPrefix(l, "class ") {
+				out = append(out, strings.Fields(l)[1])
+			}
+		}
+		return
+	}
+
+	for order, exp := range map[struct2ts.Order]string{
+		struct2ts.OrderTopological:  "Base OtherStruct ComplexStruct Model Tagged User",
+		struct2ts.OrderAlphabetical: "Base ComplexStruct Model OtherStruct Tagged User",
+		struct2ts.OrderSource:       "OtherStruct ComplexStruct Base Tagged Model User",
+	} {
+		a := strings.Join(classes(order, User{}, ComplexStruct{}, Tagged{}), " ")
+		b := strings.Join(classes(order, Tagged{}, User{}, ComplexStruct{}), " ")
+		if a != exp || b != exp {
+			t.Fatalf("%s: expected %q, got %q and %q", order, exp, a, b)
+		}
+	}
+}
+
+type ReadonlyStruct struct {
+	Name  string            `json:"name" ts:",readonly"`
+	Tags  []string          `json:"tags" ts:",readonly"`
+	Point [2]int            `json:"point" ts:",readonly"`
+	Attrs map[string]string `json:"attrs" ts:",no-null,readonly"`
+	Count int               `json:"count"`
+}
+
+func Example_readonly() {
+	s2ts := struct2ts.New(&struct2ts.Options{NoHelpers: true, NoFactories: true, NoExports: true, NoToObject: true})
+	s2ts.Add(ReadonlyStruct{})
+	s2ts.RenderTo(os.Stdout)
+
+	s2ts = struct2ts.New(&struct2ts.Options{NoHelpers: true, NoFactories: true, NoExports: true, NoToObject: true, Readonly: true, Freeze: true})
+	s2ts.Add(OtherStruct{})
+	s2ts.RenderTo(os.Stdout)
+
+	// Output:
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.ReadonlyStruct
+	// class ReadonlyStruct {
+	// 	readonly name: string;
+	// 	readonly tags: ReadonlyArray<string> | null;
+	// 	readonly point: readonly [number, number];
+	// 	readonly attrs: Readonly<{ [key: string]: string }> | null;
+	// 	count: number;
+	//
+	// 	constructor(data?: any) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.name = ('name' in d) ? d.name as string : '';
+	// 		this.tags = ('tags' in d) ? d.tags as ReadonlyArray<string> : null;
+	// 		this.point = (Array.isArray(d.point) && d.point.length === 2) ? d.point as readonly [number, number] : [0, 0];
+	// 		this.attrs = ('attrs' in d) ? d.attrs as Readonly<{ [key: string]: string }> : null;
+	// 		this.count = ('count' in d) ? d.count as number : 0;
+	// 	}
+	// }
+	//
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.OtherStruct
+	// class OtherStruct {
+	// 	readonly t: Date;
+	//
+	// 	constructor(data?: any) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.t = ('t' in d) ? ParseDate(d.t) : new Date();
+	// 		if (new.target === OtherStruct) Object.freeze(this);
+	// 	}
+	//
+	// 	with(patch: Partial<OtherStruct>): OtherStruct {
+	// 		const o = Object.assign(Object.create(Object.getPrototypeOf(this)), this, patch);
+	// 		return Object.isFrozen(this) ? Object.freeze(o) : o;
+	// 	}
+	// }
+}
+
+type CloneStruct struct {
+	Name    string                 `json:"name"`
+	Created time.Time              `json:"created"`
+	Other   *OtherStruct           `json:"other"`
+	Others  []OtherStruct          `json:"others"`
+	Tags    []string               `json:"tags"`
+	Times   map[string]time.Time   `json:"times"`
+	Extra   map[string]interface{} `json:"extra"`
+	Matrix  [][]string             `json:"matrix"`
+	Groups  map[string][]string    `json:"groups"`
+}
+
+func Example_cloneEquals() {
+	s2ts := struct2ts.New(&struct2ts.Options{NoHelpers: true, NoExports: true, NoConstructor: true, NoToObject: true, NoAssignDefaults: true, Clone: true, Equals: true})
+	s2ts.Add(CloneStruct{})
+	s2ts.RenderTo(os.Stdout)
+
+	// Output:
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.OtherStruct
+	// class OtherStruct {
+	// 	t: Date;
+	//
+	// 	clone(): OtherStruct {
+	// 		const o: any = Object.create(Object.getPrototypeOf(this));
+	// 		o.t = new Date(this.t.getTime());
+	// 		return o;
+	// 	}
+	//
+	// 	equals(other?: OtherStruct | null): boolean {
+	// 		if (this === other) return true;
+	// 		if (!other) return false;
+	// 		if (!DateEquals(this.t, other.t)) return false;
+	// 		return true;
+	// 	}
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.CloneStruct
+	// class CloneStruct {
+	// 	name: string;
+	// 	created: Date;
+	// 	other: OtherStruct | null;
+	// 	others: OtherStruct[] | null;
+	// 	tags: string[] | null;
+	// 	times: { [key: string]: Date } | null;
+	// 	extra: { [key: string]: any } | null;
+	// 	matrix: (string[] | null)[] | null;
+	// 	groups: { [key: string]: string[] | null } | null;
+	//
+	// 	clone(): CloneStruct {
+	// 		const o: any = Object.create(Object.getPrototypeOf(this));
+	// 		o.name = this.name;
+	// 		o.created = new Date(this.created.getTime());
+	// 		o.other = (this.other == null ? this.other : this.other.clone());
+	// 		o.others = (this.others == null ? this.others : this.others.map((v: any) => v.clone()));
+	// 		o.tags = (this.tags == null ? this.tags : this.tags.slice());
+	// 		o.times = (this.times == null ? this.times : ParseMap(this.times, (v: any) => new Date(v.getTime())));
+	// 		o.extra = (this.extra == null ? this.extra : Object.assign({}, this.extra));
+	// 		o.matrix = (this.matrix == null ? this.matrix : this.matrix.map((v: any) => (v == null ? v : v.slice())));
+	// 		o.groups = (this.groups == null ? this.groups : ParseMap(this.groups, (v: any) => (v == null ? v : v.slice())));
+	// 		return o;
+	// 	}
+	//
+	// 	equals(other?: CloneStruct | null): boolean {
+	// 		if (this === other) return true;
+	// 		if (!other) return false;
+	// 		if (this.name !== other.name) return false;
+	// 		if (!DateEquals(this.created, other.created)) return false;
+	// 		if (!(this.other == null ? this.other === other.other : this.other.equals(other.other))) return false;
+	// 		if (!ArrayEquals(this.others, other.others, (a: any, b: any) => (a == null ? a === b : a.equals(b)))) return false;
+	// 		if (!ArrayEquals(this.tags, other.tags)) return false;
+	// 		if (!MapEquals(this.times, other.times, (a: any, b: any) => DateEquals(a, b))) return false;
+	// 		if (!MapEquals(this.extra, other.extra, (a: any, b: any) => JSON.stringify(a) === JSON.stringify(b))) return false;
+	// 		if (!ArrayEquals(this.matrix, other.matrix, (a: any, b: any) => ArrayEquals(a, b))) return false;
+	// 		if (!MapEquals(this.groups, other.groups, (a: any, b: any) => ArrayEquals(a, b))) return false;
+	// 		return true;
+	// 	}
+	// }
+}
+
+func Example_typedInit() {
+	s2ts := struct2ts.New(&struct2ts.Options{NoHelpers: true, NoExports: true, NoToObject: true, NoFactories: true, TypedInit: true, ExtendEmbedded: true})
+	s2ts.Add(User{})
+	s2ts.Add(CloneStruct{})
+	s2ts.RenderTo(os.Stdout)
+
+	// Output:
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Model
+	// interface ModelInit {
+	// 	id: number;
+	// 	created: Date | number | string;
+	// }
+	//
+	// class Model {
+	// 	id: number;
+	// 	created: Date;
+	//
+	// 	constructor(data?: Partial<ModelInit>) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.id = ('id' in d) ? d.id as number : 0;
+	// 		this.created = ('created' in d) ? ParseDate(d.created) : new Date();
+	// 	}
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.User
+	// interface UserInit extends ModelInit {
+	// 	name: string;
+	// }
+	//
+	// class User extends Model {
+	// 	name: string;
+	//
+	// 	constructor(data?: Partial<UserInit>) {
+	// 		super(data);
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.name = ('name' in d) ? d.name as string : '';
+	// 	}
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.OtherStruct
+	// interface OtherStructInit {
+	// 	t: Date | number | string;
+	// }
+	//
+	// class OtherStruct {
+	// 	t: Date;
+	//
+	// 	constructor(data?: Partial<OtherStructInit>) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.t = ('t' in d) ? ParseDate(d.t) : new Date();
+	// 	}
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.CloneStruct
+	// interface CloneStructInit {
+	// 	name: string;
+	// 	created: Date | number | string;
+	// 	other: Partial<OtherStructInit> | null;
+	// 	others: Partial<OtherStructInit>[] | null;
+	// 	tags: string[] | null;
+	// 	times: { [key: string]: Date | number | string } | null;
+	// 	extra: { [key: string]: any } | null;
+	// 	matrix: (string[] | null)[] | null;
+	// 	groups: { [key: string]: string[] | null } | null;
+	// }
+	//
+	// class CloneStruct {
+	// 	name: string;
+	// 	created: Date;
+	// 	other: OtherStruct | null;
+	// 	others: OtherStruct[] | null;
+	// 	tags: string[] | null;
+	// 	times: { [key: string]: Date } | null;
+	// 	extra: { [key: string]: any } | null;
+	// 	matrix: (string[] | null)[] | null;
+	// 	groups: { [key: string]: string[] | null } | null;
+	//
+	// 	constructor(data?: Partial<CloneStructInit>) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.name = ('name' in d) ? d.name as string : '';
+	// 		this.created = ('created' in d) ? ParseDate(d.created) : new Date();
+	// 		this.other = (d.other != null) ? new OtherStruct(d.other) : null;
+	// 		this.others = Array.isArray(d.others) ? d.others.map((v: any) => new OtherStruct(v)) : null;
+	// 		this.tags = ('tags' in d) ? d.tags as string[] : null;
+	// 		this.times = ParseMap(d.times, (v: any) => ParseDate(v), null) as { [key: string]: Date };
+	// 		this.extra = ('extra' in d) ? d.extra as { [key: string]: any } : null;
+	// 		this.matrix = ('matrix' in d) ? d.matrix as (string[] | null)[] : null;
+	// 		this.groups = ('groups' in d) ? d.groups as { [key: string]: string[] | null } : null;
+	// 	}
+	// }
+}
+
+type DateFormats struct {
+	Created  time.Time            `json:"created"`
+	Updated  int64                `json:"updated" ts:"date:unixms"`
+	Expires  int64                `json:"expires" ts:"date:unix"`
+	Seen     []int64              `json:"seen" ts:"date:unixnano"`
+	Deadline map[string]time.Time `json:"deadline" ts:"date:rfc3339"`
+	LoginTS  int64                `json:"loginTS"`
+}
+
+func TestInvalidDateFormat(t *testing.T) {
+	s := struct2ts.New(nil)
+	st := s.Add(struct {
+		A int64       `json:"a" ts:"date:unixms"`
+		B int64       `json:"b" ts:"date:weeks"`
+		C time.Time   `json:"c" ts:"date:unixms"`
+		D []time.Time `json:"d" ts:"date:unix"`
+		E time.Time   `json:"e" ts:"date:rfc3339"`
+	}{})
+	if len(st.Fields) != 2 || st.Fields[0].DateFormat != struct2ts.DateUnixMs || st.Fields[1].DateFormat != struct2ts.DateRFC3339 {
+		t.Fatalf("unexpected fields: %+v", st.Fields)
+	}
+
+	errs, ok := s.Err().(struct2ts.Errors)
+	if !ok || len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", s.Err())
+	}
+	for i, exp := range []struct2ts.DateFormat{"weeks", struct2ts.DateUnixMs, struct2ts.DateUnix} {
+		if e, ok := errs[i].(*struct2ts.InvalidDateFormatError); !ok || e.Format != exp {
+			t.Fatalf("unexpected error: %v", errs[i])
+		}
+	}
+}
+
+func Example_dateFormats() {
+	s2ts := struct2ts.New(&struct2ts.Options{NoHelpers: true, NoExports: true, NoFactories: true})
+	s2ts.Add(DateFormats{})
+	s2ts.RenderTo(os.Stdout)
+
+	// Output:
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.DateFormats
+	// class DateFormats {
+	// 	created: Date;
+	// 	updated: Date;
+	// 	expires: Date;
+	// 	seen: Date[] | null;
+	// 	deadline: { [key: string]: Date } | null;
+	// 	loginTS: Date;
+	//
+	// 	constructor(data?: any) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.created = ('created' in d) ? ParseDate(d.created) : new Date();
+	// 		this.updated = ('updated' in d) ? ParseDate(d.updated, 'unixms') : new Date();
+	// 		this.expires = ('expires' in d) ? ParseDate(d.expires, 'unix') : new Date();
+	// 		this.seen = Array.isArray(d.seen) ? d.seen.map((v: any) => ParseDate(v, 'unixnano')) : null;
+	// 		this.deadline = ParseMap(d.deadline, (v: any) => ParseDate(v, 'rfc3339'), null) as { [key: string]: Date };
+	// 		this.loginTS = ('loginTS' in d) ? ParseDate(d.loginTS) : new Date();
+	// 	}
+	//
+	// 	toObject(): any {
+	// 		const cfg: any = {};
+	// 		cfg.created = 'string';
+	// 		cfg.updated = 'unixms';
+	// 		cfg.seen = 'unixnano,null';
+	// 		cfg.deadline = 'string,null';
+	// 		return ToObject(this, cfg);
+	// 	}
+	//
+	// 	toJSON(): any {
+	// 		return this.toObject();
+	// 	}
+	// }
+}
+
+type ZeroDates struct {
+	Created time.Time   `json:"created"`
+	Updated time.Time   `json:"updated,omitzero"`
+	Deleted *time.Time  `json:"deleted"`
+	LoginTS int64       `json:"loginTS,omitempty"`
+	Seen    []time.Time `json:"seen"`
+}
+
+func Example_zeroDates() {
+	s2ts := struct2ts.New(&struct2ts.Options{NoHelpers: true, NoExports: true, NoFactories: true, ZeroDate: struct2ts.ZeroDateNull})
+	s2ts.Add(ZeroDates{})
+	s2ts.RenderTo(os.Stdout)
+
+	// Output:
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.ZeroDates
+	// class ZeroDates {
+	// 	created: Date | null;
+	// 	updated: Date | null;
+	// 	deleted: Date | null;
+	// 	loginTS: Date | null;
+	// 	seen: (Date | null)[] | null;
+	//
+	// 	constructor(data?: any) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.created = ('created' in d) ? ParseNullDate(d.created, '', null) : null;
+	// 		this.updated = ('updated' in d) ? ParseNullDate(d.updated, '', null) : null;
+	// 		this.deleted = ('deleted' in d) ? ParseNullDate(d.deleted, '', null) : null;
+	// 		this.loginTS = ('loginTS' in d) ? ParseNullDate(d.loginTS, '', null) : null;
+	// 		this.seen = Array.isArray(d.seen) ? d.seen.map((v: any) => ParseNullDate(v, '', null)) : null;
+	// 	}
+	//
+	// 	toObject(): any {
+	// 		const cfg: any = {};
+	// 		cfg.created = 'string,zero';
+	// 		cfg.updated = 'string,omitzero';
+	// 		cfg.deleted = 'string,null';
+	// 		cfg.loginTS = ',omitempty';
+	// 		cfg.seen = 'string,null';
+	// 		return ToObject(this, cfg);
+	// 	}
+	//
+	// 	toJSON(): any {
+	// 		return this.toObject();
+	// 	}
+	// }
+}
+
+type Status string
+
+const (
+	StatusActive Status = "active"
+	StatusBanned Status = "banned"
+)
+
+type Level int
+
+const (
+	LevelLow Level = iota + 1
+	LevelHigh
+)
+
+type Account struct {
+	Model
+	Status Status           `json:"status"`
+	Levels map[string]Level `json:"levels"`
+	Data   Data             `json:"data"`
+}
+
+func TestIR(t *testing.T) {
+	opts := &struct2ts.Options{ExtendEmbedded: true, ZeroDate: struct2ts.ZeroDateNull, Clone: true, Equals: true}
+	s := struct2ts.New(opts)
+	s.Add(Account{})
+	s.Add(ComplexStruct{})
+
+	var exp, irBuf bytes.Buffer
+	if err := s.RenderTo(&exp); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteIR(&irBuf); err != nil {
+		t.Fatal(err)
+	}
+
+	ir, err := struct2ts.ReadIR(&irBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := ir.Structs[1]
+	if st.Name != "Account" || st.GoName != "Account" || len(st.Extends) != 1 || st.Extends[0] != "Model" ||
+		st.Pos == nil || st.Pos.File != "s2ts_test.go" || st.Pos.Line == 0 {
+		t.Fatalf("unexpected struct: %+v", st)
+	}
+
+	var aliases []string
+	for _, a := range ir.Aliases {
+		aliases = append(aliases, a.Name+"="+a.Type)
+	}
+	if exp := "Data={ [key: string]: any },Level=number,Status=string"; strings.Join(aliases, ",") != exp {
+		t.Fatalf("expected %s, got %s", exp, strings.Join(aliases, ","))
+	}
+
+	var enums []string
+	for _, e := range ir.Enums {
+		for _, v := range e.Values {
+			enums = append(enums, fmt.Sprintf("%s.%s=%v", e.Name, v.Name, v.Value))
+		}
+	}
+	if exp := "Level.LevelLow=1,Level.LevelHigh=2,Status.StatusActive=active,Status.StatusBanned=banned"; strings.Join(enums, ",") != exp {
+		t.Fatalf("expected %s, got %s", exp, strings.Join(enums, ","))
+	}
+
+	s2, err := struct2ts.FromIR(ir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got bytes.Buffer
+	if err := s2.RenderTo(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != exp.String() {
+		t.Fatalf("rendering the IR doesn't match:\n%s\n---\n%s", got.String(), exp.String())
+	}
+
+	ir.Version++
+	var bad bytes.Buffer
+	json.NewEncoder(&bad).Encode(ir)
+	if _, err := struct2ts.ReadIR(&bad); err == nil {
+		t.Fatal("expected an error for an unknown IR version")
+	}
+}
+
+func TestDefaultTemplate(t *testing.T) {
+	for _, opts := range []struct2ts.Options{
+		{},
+		{Indent: "  ", MarkOptional: true, NoConstructor: true},
+		{InterfaceOnly: true},
+		{InterfaceOnly: true, NoHelpers: true},
+		{ExtendEmbedded: true, TypedInit: true, Readonly: true, Freeze: true, Clone: true, Equals: true},
+		{ZeroDate: struct2ts.ZeroDateNull, NoExports: true, NoFactories: true, NoToObject: true},
+		{ES6: true},
+		{ES6: true, ExtendEmbedded: true, Readonly: true, Freeze: true, Clone: true, Equals: true},
+		{ES6: true, NoConstructor: true, NoHelpers: true},
+		{ES6: true, InterfaceOnly: true},
+		{ES6: true, InterfaceOnly: true, NoHelpers: true},
+	} {
+		opts := opts
+		s := struct2ts.New(&opts)
+		s.Add(ComplexStruct{})
+		s.Add(Account{})
+		s.Add(CloneStruct{})
+		s.Add(testmodel1.Struct2{})
+		s.Add(testmodel1.Struct3{})
+		s.Add(Order{})
+		s.Add(Person{})
+
+		var exp, got bytes.Buffer
+		if err := s.RenderTo(&exp); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.RenderTemplate(&got, struct2ts.DefaultTemplate(&opts)); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != exp.String() {
+			t.Fatalf("%+v: the template doesn't match RenderTo:\n%s\n---\n%s", opts, got.String(), exp.String())
+		}
+	}
+}
+
+// typeAliasRenderer renders `type X = {...}` instead of interfaces.
+type typeAliasRenderer struct {
+	*struct2ts.InterfaceRenderer
+}
+
+func (r typeAliasRenderer) RenderStructStart(w io.Writer, s *struct2ts.Struct) error {
+	_, err := fmt.Fprintf(w, "export type %s = {\n", s.Name)
+	return err
+}
+
+func (r typeAliasRenderer) RenderStructEnd(w io.Writer, s *struct2ts.Struct) error {
+	_, err := io.WriteString(w, "};")
+	return err
+}
+
+func Example_renderer() {
+	opts := &struct2ts.Options{NoHelpers: true, MarkOptional: true}
+	opts.Renderer = typeAliasRenderer{struct2ts.NewInterfaceRenderer(opts)}
+
+	s := struct2ts.New(opts)
+	s.Add(OtherStruct{})
+	s.Add(ReadonlyStruct{})
+	s.RenderTo(os.Stdout)
+
+	// Output:
+	// // structs
+	// export type OtherStruct = {
+	// 	t?: Date;
+	// };
+	//
+	// export type ReadonlyStruct = {
+	// 	readonly name: string;
+	// 	readonly tags: ReadonlyArray<string> | null;
+	// 	readonly point: readonly [number, number];
+	// 	readonly attrs: Readonly<{ [key: string]: string }> | null;
+	// 	count: number;
+	// };
+}
+
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+func (Money) TypescriptField(f *struct2ts.Field) *struct2ts.FieldOverride {
+	return &struct2ts.FieldOverride{
+		Type:     "Money",
+		Ctor:     fmt.Sprintf("Money.parse(d.%s)", f.Name),
+		ToObject: "v && v.toJSON()",
+	}
+}
+
+type Invoice struct {
+	Total Money   `json:"total"`
+	Tax   *Money  `json:"tax"`
+	Ratio float64 `json:"ratio"`
+}
+
+func Example_fieldOverride() {
+	s := struct2ts.New(&struct2ts.Options{NoHelpers: true, NoExports: true, Equals: true})
+	s.OverrideField(Invoice{}, "Ratio", func(f *struct2ts.Field) *struct2ts.FieldOverride {
+		return &struct2ts.FieldOverride{Default: "1", ToObject: "Math.round(v * 100) / 100"}
+	})
+	s.Add(Invoice{})
+	s.RenderTo(os.Stdout)
+
+	// Output:
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Invoice
+	// class Invoice {
+	// 	total: Money;
+	// 	tax: Money | null;
+	// 	ratio: number;
+	//
+	// 	constructor(data?: any) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.total = Money.parse(d.total);
+	// 		this.tax = Money.parse(d.tax);
+	// 		this.ratio = ('ratio' in d) ? d.ratio as number : 1;
+	// 	}
+	//
+	// 	static fromJSON(json: string): Invoice {
+	// 		return new Invoice(JSON.parse(json));
+	// 	}
+	//
+	// 	static fromArray(data?: any[] | any): Invoice[] | null {
+	// 		return FromArray(Invoice, data);
+	// 	}
+	//
+	// 	toObject(): any {
+	// 		const cfg: any = {};
+	// 		cfg.total = (v: any) => v && v.toJSON();
+	// 		cfg.tax = (v: any) => v && v.toJSON();
+	// 		cfg.ratio = (v: any) => Math.round(v * 100) / 100;
+	// 		return ToObject(this, cfg);
+	// 	}
+	//
+	// 	toJSON(): any {
+	// 		return this.toObject();
+	// 	}
+	//
+	// 	equals(other?: Invoice | null): boolean {
+	// 		if (this === other) return true;
+	// 		if (!other) return false;
+	// 		if (JSON.stringify(this.total) !== JSON.stringify(other.total)) return false;
+	// 		if (JSON.stringify(this.tax) !== JSON.stringify(other.tax)) return false;
+	// 		if (this.ratio !== other.ratio) return false;
+	// 		return true;
+	// 	}
+	// }
+}
+
+func TestOverrideFieldErrors(t *testing.T) {
+	s := struct2ts.New(nil)
+	if err := s.OverrideField(Invoice{}, "Missing", nil); err == nil {
+		t.Fatal("expected an error for a missing field")
+	}
+	if err := s.OverrideField(Money{}.Amount, "Amount", nil); err == nil {
+		t.Fatal("expected an error for a non-struct type")
+	}
+}
+
+type Order struct {
+	ID    int  `json:"id"`
+	Buyer User `json:"buyer"`
+}
+
+func (Order) RenderCustomTypescriptV2(ctx *struct2ts.CustomContext, w io.Writer) error {
+	ctx.AddImport("{ formatID }", "./ids")
+
+	ret := ": string"
+	if ctx.Options.ES6 {
+		ret = ""
+	}
+
+	fmt.Fprintf(w, "get label()%s {\n", ret)
+	fmt.Fprintf(w, "\treturn '%s ' + formatID(this.id) + ' (%s ' + this.buyer.name + ')';\n}", ctx.Struct.Name, ctx.Name(User{}))
+	return nil
+}
+
+func Example_customContext() {
+	s := struct2ts.New(&struct2ts.Options{NoHelpers: true, NoExports: true, NoConstructor: true, NoToObject: true})
+	s.AddWithName(User{}, "Customer")
+	s.AddWithName(Order{}, "PurchaseOrder")
+	s.RenderTo(os.Stdout)
+
+	// Output:
+	// import { formatID } from './ids';
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Customer
+	// class Customer {
+	// 	id: number = 0;
+	// 	created: Date = new Date();
+	// 	name: string = '';
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.PurchaseOrder
+	// class PurchaseOrder {
+	// 	id: number = 0;
+	// 	buyer: Customer = new Customer();
+	//
+	// 	get label(): string {
+	// 		return 'PurchaseOrder ' + formatID(this.id) + ' (Customer ' + this.buyer.name + ')';
+	// 	}
+	// }
+}
+
+func TestImports(t *testing.T) {
+	for _, tc := range []struct {
+		opts struct2ts.Options
+		imp  string
+	}{
+		{struct2ts.Options{}, "import { formatID } from './ids';"},
+		{struct2ts.Options{InterfaceOnly: true}, "import { formatID } from './ids';"},
+		{struct2ts.Options{ES6: true}, "const { formatID } = require('./ids');"},
+		{struct2ts.Options{ES6: true, InterfaceOnly: true}, ""}, // the custom output isn't rendered
+		{struct2ts.Options{Renderer: typeAliasRenderer{struct2ts.NewInterfaceRenderer(&struct2ts.Options{})}}, ""},
+	} {
+		opts := tc.opts
+		s := struct2ts.New(&opts)
+		s.Add(Order{})
+
+		var buf bytes.Buffer
+		if err := s.RenderTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		out := strings.TrimPrefix(buf.String(), "'use strict';\n")
+		if tc.imp == "" && strings.Contains(out, "./ids") || tc.imp != "" && !strings.HasPrefix(out, tc.imp+"\n") {
+			t.Fatalf("%+v: expected the import %q:\n%s", tc.opts, tc.imp, out)
+		}
+	}
+}
+
+type Person struct {
+	First string            `json:"first"`
+	Last  string            `json:"last"`
+	Age   int               `json:"age"`
+	Tags  []string          `json:"tags"`
+	Meta  map[string]string `json:"meta"`
+	Seen  int64             `json:"seen" ts:"date"`
+
+	Avatar string `json:"-" ts:"computed:avatarURL"`
+}
+
+// FullName is translated to a getter.
+//
+//ts:computed:fullName
+func (p Person) FullName() string { return strings.TrimSpace(p.First + " " + p.Last) }
+
+//ts:computed
+func (p *Person) Greeting() string { return "Hi, " + p.FullName() + "!" }
+
+//ts:computed:active
+func (p Person) IsActive() bool { return p.Age >= 18 && (len(p.Tags) > 0 || len(p.Meta) != 0) }
+
+// Initials can't be translated, so it's only declared.
+//
+//ts:computed:initials
+func (p Person) Initials() string {
+	if p.First == "" {
+		return ""
+	}
+	return p.First[:1] + p.Last[:1]
+}
+
+// NameLen isn't translated, len counts bytes in Go and UTF-16 code units in JS.
+//
+//ts:computed:nameLen
+func (p Person) NameLen() int { return len(p.First) }
+
+// Untagged isn't translated, `===` doesn't compare slices with nil like Go does.
+//
+//ts:computed:untagged
+func (p Person) Untagged() bool { return p.Tags == nil }
+
+// SeenMs and Recent aren't translated, Seen is a Date in TS.
+//
+//ts:computed:seenMs
+func (p Person) SeenMs() int64 { return p.Seen * 1000 }
+
+//ts:computed:recent
+func (p Person) Recent() bool { return p.Seen >= 1e9 }
+
+//ts:computed:named
+func (p Person) Named() bool { return p.FullName() != "" && p.IsActive() == (p.Age >= 18) }
+
+// String isn't marked.
+func (p Person) String() string { return p.FullName() }
+
+func Example_computed() {
+	s := struct2ts.New(&struct2ts.Options{NoHelpers: true, NoExports: true, NoFactories: true, NoToObject: true})
+	s.Add(Person{})
+	s.RenderTo(os.Stdout)
+
+	// Output:
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Person
+	// class Person {
+	// 	first: string;
+	// 	last: string;
+	// 	age: number;
+	// 	tags: string[] | null;
+	// 	meta: { [key: string]: string } | null;
+	// 	seen: Date;
+	// 	declare readonly avatarURL: string;
+	// 	declare readonly initials: string;
+	// 	declare readonly nameLen: number;
+	// 	declare readonly untagged: boolean;
+	// 	declare readonly seenMs: number;
+	// 	declare readonly recent: boolean;
+	//
+	// 	constructor(data?: any) {
+	// 		const d: any = (data && typeof data === 'object') ? ParseObject(data) : {};
+	// 		this.first = ('first' in d) ? d.first as string : '';
+	// 		this.last = ('last' in d) ? d.last as string : '';
+	// 		this.age = ('age' in d) ? d.age as number : 0;
+	// 		this.tags = ('tags' in d) ? d.tags as string[] : null;
+	// 		this.meta = ('meta' in d) ? d.meta as { [key: string]: string } : null;
+	// 		this.seen = ('seen' in d) ? ParseDate(d.seen) : new Date();
+	// 	}
+	//
+	// 	get fullName(): string {
+	// 		return (this.first + ' ' + this.last).trim();
+	// 	}
+	//
+	// 	get Greeting(): string {
+	// 		return 'Hi, ' + this.fullName + '!';
+	// 	}
+	//
+	// 	get active(): boolean {
+	// 		return this.age >= 18 && ((this.tags || []).length > 0 || Object.keys(this.meta || {}).length !== 0);
+	// 	}
+	//
+	// 	get named(): boolean {
+	// 		return this.fullName !== '' && this.active === (this.age >= 18);
+	// 	}
+	// }
+}
+
+func TestComputedInterface(t *testing.T) {
+	s := struct2ts.New(&struct2ts.Options{InterfaceOnly: true, NoHelpers: true})
+	s.Add(Person{})
+
+	var buf bytes.Buffer
+	if err := s.RenderTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "fullName") || strings.Contains(buf.String(), "avatarURL") {
+		t.Fatalf("the interface shouldn't have getters:\n%s", buf.String())
+	}
+}
+
+type Timestamps struct {
+	Created time.Time `bson:"created" json:"createdAt"`
+}
+
+type Contact struct {
+	Email string `msgpack:"e" json:"email"`
+}
+
+type Customer struct {
+	Timestamps `bson:",inline"`
+	ID         string  `bson:"_id" json:"id"`
+	Name       string  `bson:"name,omitempty" json:"name"`
+	Secret     string  `bson:"-" json:"secret"`
+	Contact    Contact `json:",inline"`
+	Notes      string
+}
+
+func Example_tagNames() {
+	for _, tags := range [][]string{{"json"}, {"bson", "json"}, {"msgpack", "bson"}} {
+		s2ts := struct2ts.New(&struct2ts.Options{InterfaceOnly: true, MarkOptional: true, NoHelpers: true, NoExports: true, TagNames: tags})
+		s2ts.Add(Customer{})
+		s2ts.RenderTo(os.Stdout)
+	}
+
+	// Output:
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Contact
+	// interface Contact {
+	// 	email: string;
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Customer
+	// interface Customer {
+	// 	createdAt: Date;
+	// 	id: string;
+	// 	name: string;
+	// 	secret: string;
+	// 	Contact: Contact;
+	// 	Notes: string;
+	// }
+	//
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Contact
+	// interface Contact {
+	// 	email: string;
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Customer
+	// interface Customer {
+	// 	created: Date;
+	// 	_id: string;
+	// 	name?: string;
+	// 	contact: Contact;
+	// 	notes: string;
+	// }
+	//
+	// // structs
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Contact
+	// interface Contact {
+	// 	e: string;
+	// }
+	//
+	// // struct2ts:github.com/OneOfOne/struct2ts_test.Customer
+	// interface Customer {
+	// 	created: Date;
+	// 	_id: string;
+	// 	name?: string;
+	// 	Contact: Contact;
+	// 	Notes: string;
+	// }
+}
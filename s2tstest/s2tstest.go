@@ -0,0 +1,309 @@
+// Package s2tstest checks that the classes generated by struct2ts round-trip real data:
+// values are marshaled with encoding/json, passed to the generated constructor and serialized back with toObject,
+// the result is then compared to the original JSON. So is a copy made by passing the instance to the constructor.
+//
+// It needs a JS runtime, node or deno, installed locally.
+package s2tstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/OneOfOne/struct2ts"
+)
+
+// ErrNoRuntime is returned by RoundTrip if neither node nor deno are installed.
+var ErrNoRuntime = errors.New("s2tstest: no JS runtime found (node or deno)")
+
+// Mismatch is a difference between encoding/json's output and toObject's.
+type Mismatch struct {
+	Value int    // the index of the value passed to RoundTrip
+	Type  string // the TS class name
+	Path  string // the JSON path of the field, for example `.users[0].tags["x"]`
+	Copy  bool   // the mismatch is in the copy of the instance, `new Class(instance)`
+
+	// Go and TS are the JSON encoded values, an empty string means the field is missing.
+	Go, TS string
+}
+
+func (m Mismatch) String() string {
+	path := m.Path
+	if m.Copy {
+		path += " (copy)"
+	}
+	return fmt.Sprintf("%s#%d%s: go %s, ts %s", m.Type, m.Value, path, orMissing(m.Go), orMissing(m.TS))
+}
+
+func orMissing(s string) string {
+	if s == "" {
+		return "<missing>"
+	}
+	return s
+}
+
+// Runtime returns the command used to run JS, node or deno, or nil if neither is installed.
+func Runtime() []string {
+	if p, err := exec.LookPath("node"); err == nil {
+		return []string{p}
+	}
+	if p, err := exec.LookPath("deno"); err == nil {
+		return []string{p, "run", "--quiet"}
+	}
+	return nil
+}
+
+// RoundTrip generates ES6 classes for the types of values using opts and checks that
+// `new Class(json).toObject()` and `new Class(new Class(json)).toObject()` match the encoding/json output of every value,
+// it returns all the fields that differ.
+// opts may be nil, the options that are needed for the round-trip (ES6, the ctor, toObject and the helpers) are always enabled.
+func RoundTrip(opts *struct2ts.Options, values ...interface{}) (_ []Mismatch, err error) {
+	rt := Runtime()
+	if rt == nil {
+		return nil, ErrNoRuntime
+	}
+
+	var o struct2ts.Options
+	if opts != nil {
+		o = *opts
+	}
+	o.ES6, o.NoExports, o.NoHelpers = true, true, false
+	o.NoConstructor, o.NoToObject, o.InterfaceOnly = false, false, false
+
+	type testCase struct {
+		Type string          `json:"type"`
+		Data json.RawMessage `json:"data"`
+	}
+
+	var (
+		s       = struct2ts.New(&o)
+		cases   = make([]testCase, len(values))
+		structs = make([]*struct2ts.Struct, len(values))
+		classes = map[string]bool{}
+	)
+
+	for i, v := range values {
+		if t := reflect.TypeOf(v); t == nil || indirect(t).Kind() != reflect.Struct {
+			return nil, fmt.Errorf("s2tstest: value #%d: %T is not a struct", i, v)
+		}
+
+		if cases[i].Data, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("s2tstest: value #%d: %v", i, err)
+		}
+
+		structs[i] = s.Add(v)
+	}
+
+	// adding a type can rename the ones added before it, see Options.OnNameCollision
+	for i, st := range structs {
+		cases[i].Type = st.Name
+		classes[st.Name] = true
+	}
+
+	var buf bytes.Buffer
+	if err = s.RenderTo(&buf); err != nil {
+		return
+	}
+
+	names := make([]string, 0, len(classes))
+	for name := range classes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	j, _ := json.Marshal(cases)
+	fmt.Fprintf(&buf, "\n\nconst classes = { %s };\n", strings.Join(names, ", "))
+	fmt.Fprintf(&buf, "const cases = %s;\n", j)
+	buf.WriteString("console.log(JSON.stringify(cases.map((c) => {\n")
+	buf.WriteString("\tconst v = new classes[c.type](c.data);\n")
+	buf.WriteString("\treturn [v.toObject(), new classes[c.type](v).toObject()];\n")
+	buf.WriteString("})));\n")
+
+	out, err := run(rt, buf.Bytes())
+	if err != nil {
+		return
+	}
+
+	var results [][2]json.RawMessage
+	if err = json.Unmarshal(out, &results); err != nil {
+		return nil, fmt.Errorf("s2tstest: invalid output: %v", err)
+	}
+	if len(results) != len(cases) {
+		return nil, fmt.Errorf("s2tstest: expected %d results, got %d", len(cases), len(results))
+	}
+
+	var ms []Mismatch
+	for i, c := range cases {
+		var goV interface{}
+		if err = unmarshal(c.Data, &goV); err != nil {
+			return
+		}
+
+		for j, res := range results[i] {
+			var tsV interface{}
+			if err = unmarshal(res, &tsV); err != nil {
+				return
+			}
+
+			diff(goV, tsV, "", func(path string, a, b interface{}, aok, bok bool) {
+				ms = append(ms, Mismatch{Value: i, Type: c.Type, Path: path, Copy: j == 1, Go: encode(a, aok), TS: encode(b, bok)})
+			})
+		}
+	}
+
+	return ms, nil
+}
+
+// Test calls RoundTrip and reports every mismatch as an error, the test is skipped if there's no JS runtime installed.
+func Test(t testing.TB, opts *struct2ts.Options, values ...interface{}) {
+	t.Helper()
+
+	ms, err := RoundTrip(opts, values...)
+	switch {
+	case err == ErrNoRuntime:
+		t.Skip(err)
+	case err != nil:
+		t.Fatal(err)
+	}
+
+	for _, m := range ms {
+		t.Error(m)
+	}
+}
+
+func run(rt []string, src []byte) ([]byte, error) {
+	f, err := ioutil.TempFile("", "s2tstest_*.js")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(src); err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(rt[0], append(rt[1:], f.Name())...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("s2tstest: %s: %v\n%s", rt[0], err, stderr.Bytes())
+	}
+
+	return out, nil
+}
+
+type diffFn func(path string, a, b interface{}, aok, bok bool)
+
+// diff calls fn for every value that's different between a and b.
+func diff(a, b interface{}, path string, fn diffFn) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			kp := path + "." + k
+			if !isIdent(k) {
+				kp = path + "[" + strconv.Quote(k) + "]"
+			}
+
+			x, xok := av[k]
+			y, yok := bv[k]
+			if xok && yok {
+				diff(x, y, kp, fn)
+			} else {
+				fn(kp, x, y, xok, yok)
+			}
+		}
+		return
+
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			break
+		}
+
+		for i := range av {
+			diff(av[i], bv[i], path+"["+strconv.Itoa(i)+"]", fn)
+		}
+		return
+
+	case json.Number:
+		if bv, ok := b.(json.Number); ok && numEqual(av, bv) {
+			return
+		}
+
+	default:
+		if a == b {
+			return
+		}
+	}
+
+	fn(path, a, b, true, true)
+}
+
+func numEqual(a, b json.Number) bool {
+	if a == b {
+		return true
+	}
+	x, xerr := a.Float64()
+	y, yerr := b.Float64()
+	return xerr == nil && yerr == nil && x == y
+}
+
+func unmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func encode(v interface{}, ok bool) string {
+	if !ok {
+		return ""
+	}
+	j, _ := json.Marshal(v)
+	return string(j)
+}
+
+func isIdent(s string) bool {
+	for i, c := range s {
+		if !(c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
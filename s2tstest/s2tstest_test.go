@@ -0,0 +1,176 @@
+package s2tstest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OneOfOne/struct2ts"
+	"github.com/OneOfOne/struct2ts/s2tstest"
+)
+
+type Address struct {
+	Street string `json:"street"`
+	Zip    string `json:"zip,omitempty"`
+}
+
+type User struct {
+	ID        int64              `json:"id"`
+	Name      string             `json:"name"`
+	Email     string             `json:"email,omitempty"`
+	Admin     bool               `json:"admin,omitempty"`
+	Created   time.Time          `json:"created"`
+	LastSeen  *time.Time         `json:"lastSeen"`
+	Address   *Address           `json:"address"`
+	Addresses []Address          `json:"addresses"`
+	Tags      map[string]string  `json:"tags,omitempty"`
+	Scores    map[string][]int   `json:"scores"`
+	Point     [2]float64         `json:"point"`
+	Settings  map[string]float64 `json:"settings,omitempty"`
+	Avatar    []byte             `json:"avatar,omitempty"`
+	Key       []byte             `json:"key"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 120e6, time.UTC)
+	s2tstest.Test(t, nil,
+		User{},
+		&User{
+			ID: 1, Name: "a", Email: "a@b.c", Admin: true,
+			Created: now, LastSeen: &now,
+			Address:   &Address{Street: "x"},
+			Addresses: []Address{{Street: "y", Zip: "1"}, {}},
+			Tags:      map[string]string{"a": "b"},
+			Scores:    map[string][]int{"x": {1, 2}},
+			Point:     [2]float64{1.5, -2},
+			Avatar:    []byte("png"),
+			Key:       []byte{},
+		},
+	)
+}
+
+type Pointers struct {
+	Ptrs   []*Address          `json:"ptrs"`
+	Pair   [2]*Address         `json:"pair"`
+	Times  []*time.Time        `json:"times"`
+	ByName map[string]*Address `json:"byName"`
+}
+
+func TestRoundTripNullElements(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	s2tstest.Test(t, nil,
+		Pointers{},
+		Pointers{
+			Ptrs:   []*Address{nil, {Street: "x"}},
+			Pair:   [2]*Address{nil, {Zip: "1"}},
+			Times:  []*time.Time{&now, nil},
+			ByName: map[string]*Address{"a": nil, "b": {Street: "x"}},
+		},
+	)
+}
+
+type Nils struct {
+	Map    map[string]int            `json:"map"`
+	Values map[string]Address        `json:"values"`
+	Nested map[string]map[string]int `json:"nested"`
+	Any    interface{}               `json:"any"`
+	Grid   [][]Address               `json:"grid"`
+	Lists  map[string][]string       `json:"lists"`
+	Bytes  [][]byte                  `json:"bytes"`
+}
+
+func TestRoundTripNils(t *testing.T) {
+	s2tstest.Test(t, nil,
+		Nils{},
+		Nils{
+			Map:    map[string]int{},
+			Nested: map[string]map[string]int{"a": nil, "b": {"x": 1}},
+			Any:    map[string]interface{}{"a": nil},
+			Grid:   [][]Address{nil, {{Street: "x"}}},
+			Lists:  map[string][]string{"a": nil, "b": {}},
+			Bytes:  [][]byte{nil, []byte("x")},
+		},
+	)
+}
+
+type Dates struct {
+	Created time.Time   `json:"created"`
+	Seen    []time.Time `json:"seen"`
+	Updated int64       `json:"updated" ts:"date"`
+	LoginTS int64       `json:"loginTS"`
+}
+
+func TestRoundTripDateFormat(t *testing.T) {
+	// time.Time stays RFC 3339, only the numeric dates are in milliseconds
+	now := time.Date(2020, 1, 2, 3, 4, 5, 120e6, time.UTC)
+	s2tstest.Test(t, &struct2ts.Options{DateFormat: struct2ts.DateUnixMs},
+		Dates{},
+		Dates{Created: now, Seen: []time.Time{now}, Updated: now.UnixNano() / 1e6, LoginTS: now.UnixNano() / 1e6},
+	)
+}
+
+type Quoted struct {
+	ID     int64   `json:"id,string"`
+	Price  float64 `json:"price,string,omitempty"`
+	Ref    *int    `json:"ref,string"`
+	Count  uint8   `json:"count,string,omitzero"`
+	Active bool    `json:"active,string"`
+	Admin  *bool   `json:"admin,string,omitempty"`
+	Label  string  `json:"label,string"`
+}
+
+func TestRoundTripStringOption(t *testing.T) {
+	ref, admin := 3, false
+	s2tstest.Test(t, nil, Quoted{}, Quoted{ID: 1 << 40, Price: 1.5, Ref: &ref, Count: 2, Active: true, Admin: &admin, Label: `a "b"`})
+}
+
+type Tree struct {
+	Value  int   `json:"value"`
+	Parent *Tree `json:"parent" ts:",no-null"`
+}
+
+func TestRoundTripCycle(t *testing.T) {
+	s2tstest.Test(t, nil, Tree{}, Tree{Value: 2, Parent: &Tree{Value: 1}})
+}
+
+type Contact struct {
+	*Address
+	Name string `json:"name"`
+}
+
+func TestRoundTripEmbeddedPointer(t *testing.T) {
+	s2tstest.Test(t, nil, Contact{Name: "a"}, Contact{Address: &Address{Street: "x", Zip: "1"}})
+	s2tstest.Test(t, &struct2ts.Options{ExtendEmbedded: true}, Contact{})
+}
+
+// offByOne is serialized wrong on purpose.
+type offByOne int
+
+func (offByOne) TypescriptField(*struct2ts.Field) *struct2ts.FieldOverride {
+	return &struct2ts.FieldOverride{ToObject: "v + 1"}
+}
+
+type Mismatched struct {
+	Value offByOne `json:"value"`
+}
+
+func TestRoundTripMismatch(t *testing.T) {
+	ms, err := s2tstest.RoundTrip(&struct2ts.Options{}, Mismatched{Value: 1})
+	if err == s2tstest.ErrNoRuntime {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ms) != 2 || ms[0].Path != ".value" || ms[0].Go != "1" || ms[0].TS != "2" || ms[0].Copy || !ms[1].Copy {
+		t.Fatalf("unexpected mismatches: %v", ms)
+	}
+
+	if exp := "Mismatched#0.value: go 1, ts 2"; ms[0].String() != exp {
+		t.Fatalf("expected %q, got %q", exp, ms[0].String())
+	}
+
+	if exp := "Mismatched#0.value (copy): go 1, ts 2"; ms[1].String() != exp {
+		t.Fatalf("expected %q, got %q", exp, ms[1].String())
+	}
+}
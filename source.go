@@ -0,0 +1,135 @@
+package struct2ts
+
+import (
+	"errors"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// genericName strips the type arguments from the name of an instantiated generic type.
+func genericName(name string) string {
+	if i := strings.IndexByte(name, '['); i > -1 {
+		return name[:i]
+	}
+	return name
+}
+
+// goPackage is the parsed source of a package.
+type goPackage struct {
+	path  string
+	fset  *token.FileSet
+	files []*ast.File
+	decls map[string]sourcePos // the positions of all the type declarations
+
+	types *types.Package // lazily type checked, see consts
+}
+
+// goPackages caches parsed packages by path.
+type goPackages map[string]*goPackage
+
+func (pkgs goPackages) get(pkgPath string) *goPackage {
+	p, ok := pkgs[pkgPath]
+	if !ok {
+		p = parsePackage(pkgPath)
+		pkgs[pkgPath] = p
+	}
+	return p
+}
+
+// parsePackage parses the source of the package at pkgPath, the package is empty if it can't be found,
+// external test packages (`pkg_test`) are looked up in the directory of the package they test.
+func parsePackage(pkgPath string) *goPackage {
+	out := &goPackage{path: pkgPath, fset: token.NewFileSet(), decls: map[string]sourcePos{}}
+	if pkgPath == "" {
+		return out
+	}
+
+	isTest := strings.HasSuffix(pkgPath, "_test")
+	bp, err := build.Import(strings.TrimSuffix(pkgPath, "_test"), "", build.FindOnly)
+	if err != nil {
+		return out
+	}
+
+	files, _ := filepath.Glob(filepath.Join(bp.Dir, "*.go"))
+	for _, fn := range files {
+		f, err := parser.ParseFile(out.fset, fn, nil, parser.ParseComments)
+		if err != nil || strings.HasSuffix(f.Name.Name, "_test") != isTest {
+			continue
+		}
+		out.files = append(out.files, f)
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			if ts, ok := n.(*ast.TypeSpec); ok {
+				pos := out.fset.Position(ts.Pos())
+				out.decls[ts.Name.Name] = sourcePos{pkgPath, filepath.Base(fn), pos.Line, pos.Column}
+			}
+			return true
+		})
+	}
+
+	return out
+}
+
+// methods returns the methods declared on the named type typeName, in source order.
+func (p *goPackage) methods(typeName string) (out []*ast.FuncDecl) {
+	for _, f := range p.files {
+		for _, d := range f.Decls {
+			fd, ok := d.(*ast.FuncDecl)
+			if !ok || fd.Recv == nil || len(fd.Recv.List) != 1 {
+				continue
+			}
+
+			rt := fd.Recv.List[0].Type
+			if star, ok := rt.(*ast.StarExpr); ok {
+				rt = star.X
+			}
+			if idx, ok := rt.(*ast.IndexExpr); ok { // generic receiver
+				rt = idx.X
+			}
+
+			if id, ok := rt.(*ast.Ident); ok && id.Name == typeName {
+				out = append(out, fd)
+			}
+		}
+	}
+
+	return
+}
+
+// consts returns the constants of the named type typeName in declaration order.
+func (p *goPackage) consts(typeName string) (out []*types.Const) {
+	if p.types == nil {
+		// imports aren't resolved, the package's own constants don't need them
+		conf := types.Config{
+			Importer: importerFunc(func(path string) (*types.Package, error) { return nil, errNoImports }),
+			Error:    func(error) {},
+		}
+		p.types, _ = conf.Check(p.path, p.fset, p.files, nil)
+	}
+
+	scope := p.types.Scope()
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if !ok {
+			continue
+		}
+		if n, ok := c.Type().(*types.Named); ok && n.Obj().Name() == typeName && n.Obj().Pkg() == p.types {
+			out = append(out, c)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Pos() < out[j].Pos() })
+	return
+}
+
+var errNoImports = errors.New("imports aren't resolved")
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (fn importerFunc) Import(path string) (*types.Package, error) { return fn(path) }
@@ -1,10 +1,10 @@
 package struct2ts
 
 import (
-	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"strings"
 )
 
 var zeroValues = map[string]string{
@@ -20,146 +20,196 @@ type Struct struct {
 	Name   string
 	Fields []*Field
 
-	t reflect.Type
+	// Embeds are the embedded structs that are extended rather than flattened, see Options.ExtendEmbedded.
+	Embeds []*Struct
+
+	// Computed are the getters generated from Go methods.
+	Computed []*Computed
+
+	t           reflect.Type
+	pkg, goName string     // the Go package path and type name, t is nil for structs loaded from an IR
+	pos         *sourcePos // only set for structs loaded from an IR
+	named       bool       // the name was passed to AddWithName or set by Options.Rename, see resolveNames
+
+	custom   *string  // the output of RenderCustom, rendered ahead of time by StructToTS.prepare
+	imports  []string // see CustomContext.AddImport
+	rendered bool     // custom was rendered, only the imports of rendered custom output are used
 }
 
+// RenderTo renders s with opts.Renderer or the DefaultRenderer.
 func (s *Struct) RenderTo(opts *Options, w io.Writer) (err error) {
-	if _, err = fmt.Fprintf(w, "// struct2ts:%s.%s\n", s.t.PkgPath(), s.Name); err != nil {
-		return
-	}
-
-	if opts.InterfaceOnly {
-		if opts.ES6 { // no interfaces in js
-			return
-		}
-		if !opts.NoExports {
-			fmt.Fprintf(w, "export ")
-		}
-		_, err = fmt.Fprintf(w, "interface %s {\n", s.Name)
-	} else {
-		_, err = fmt.Fprintf(w, "class %s {\n", s.Name)
-	}
+	return s.render(opts.renderer(), w)
+}
 
-	if err != nil {
+func (s *Struct) render(r Renderer, w io.Writer) (err error) {
+	if err = r.RenderStructStart(w, s); err != nil {
 		return
 	}
 
-	if !opts.ES6 {
-		if err = s.RenderFields(opts, w); err != nil {
+	for _, f := range s.Fields {
+		if err = r.RenderField(w, s, f); err != nil {
 			return
 		}
 	}
 
-	if err = s.RenderConstructor(opts, w); err != nil {
-		return
-	}
+	return r.RenderStructEnd(w, s)
+}
 
-	if err = s.RenderToObject(opts, w); err != nil {
-		return
+// extends returns the `extends` clause for s's embedded structs, suffix is appended to their names.
+func (s *Struct) extends(suffix ...string) string {
+	if len(s.Embeds) == 0 {
+		return ""
 	}
 
-	if err = s.RenderCustom(opts, w); err != nil {
-		return
+	names := make([]string, len(s.Embeds))
+	for i, e := range s.Embeds {
+		names[i] = e.Name + strings.Join(suffix, "")
 	}
 
-	_, err = fmt.Fprint(w, "}")
-	return
+	return " extends " + strings.Join(names, ", ")
 }
 
 type CustomTypescript interface {
 	RenderCustomTypescript(w io.Writer) (err error)
 }
 
-func (s *Struct) RenderCustom(opts *Options, w io.Writer) (err error) {
-	ww := newTabScanner(w, opts.indents[1])
-	ctit := reflect.TypeOf((*CustomTypescript)(nil)).Elem()
-	var implementingType reflect.Type = nil
-	if s.t.Implements(ctit) {
-		implementingType = ctit
-	}
-	if reflect.PtrTo(s.t).Implements(ctit) {
-		implementingType = reflect.PtrTo(s.t)
-	}
-	if implementingType != nil {
-		m, ok := implementingType.MethodByName("RenderCustomTypescript")
-		if !ok {
-			return errors.New("couldn't get method RenderCustomTypescript")
-		}
-		_, err = fmt.Fprintf(ww, "\n")
-		o := reflect.New(s.t)
-		if implementingType.Kind() != reflect.Ptr {
-			o = o.Elem()
-		}
-		wv := reflect.ValueOf(ww)
-		r := m.Func.Call([]reflect.Value{o, wv})
-		if len(r) > 0 && !r[0].IsNil() {
-			switch r0t := r[0].Interface().(type) {
-			case error:
-				return r0t
-			}
-		}
-		_, err = fmt.Fprintf(w, "\n")
+// CustomTypescriptV2 is CustomTypescript with a context, it's used instead of it if a type implements both.
+type CustomTypescriptV2 interface {
+	RenderCustomTypescriptV2(ctx *CustomContext, w io.Writer) (err error)
+}
+
+// CustomContext is what CustomTypescriptV2 implementations are rendered with.
+type CustomContext struct {
+	Struct  *Struct
+	Options *Options
+
+	s *StructToTS // nil if RenderCustom was called directly
+}
+
+// Name returns the TS name of v's type (a value, a reflect.Type or a reflect.Value),
+// or an empty string if it wasn't added.
+func (c *CustomContext) Name(v interface{}) string {
+	if c.s == nil {
+		return ""
 	}
 
-	return
+	if st := c.s.seen[indirect(typeOf(v))]; st != nil {
+		return st.Name
+	}
+
+	return ""
 }
 
-func (s *Struct) RenderFields(opts *Options, w io.Writer) (err error) {
-	for _, f := range s.Fields {
-		if err = f.RenderTopLevel(w, opts); err != nil {
+// AddImport adds `import spec from 'from';` to the top of the output, ES6 output is a CommonJS module
+// so it gets `const spec = require('from');` instead (`* as x` becomes `x`).
+func (c *CustomContext) AddImport(spec, from string) {
+	imp := fmt.Sprintf("import %s from '%s';", spec, from)
+	if c.Options.ES6 {
+		imp = fmt.Sprintf("const %s = require('%s');", strings.TrimPrefix(spec, "* as "), from)
+	}
+	for _, i := range c.Struct.imports {
+		if i == imp {
 			return
 		}
 	}
+	c.Struct.imports = append(c.Struct.imports, imp)
+}
 
-	return
+// Imports returns the imports added by s's CustomTypescriptV2, they're only known after
+// StructToTS.RenderTo or RenderTemplate started rendering.
+func (s *Struct) Imports() []string { return s.imports }
+
+// RenderCustom renders the output of s's CustomTypescriptV2 or CustomTypescript, if it implements one of them.
+func (s *Struct) RenderCustom(opts *Options, w io.Writer) (err error) {
+	if s.custom != nil {
+		s.rendered = true
+		_, err = io.WriteString(w, *s.custom)
+		return
+	}
+
+	return s.renderCustom(&CustomContext{Struct: s, Options: opts}, w)
 }
 
-func (s *Struct) RenderConstructor(opts *Options, w io.Writer) (err error) {
-	if opts.NoConstructor || opts.InterfaceOnly {
+func (s *Struct) renderCustom(ctx *CustomContext, w io.Writer) (err error) {
+	if s.t == nil { // loaded from an IR
+		return
+	}
+
+	var render func(io.Writer) error
+	switch ct := reflect.New(s.t).Interface().(type) { // *T has the methods of both T and *T
+	case CustomTypescriptV2:
+		render = func(w io.Writer) error { return ct.RenderCustomTypescriptV2(ctx, w) }
+	case CustomTypescript:
+		render = ct.RenderCustomTypescript
+	default:
 		return
 	}
 
-	if opts.ES6 {
-		fmt.Fprintf(w, "%sconstructor(data = null) {\n", opts.indents[1])
-		fmt.Fprintf(w, "%sconst d = (data && typeof data === 'object') ? ToObject(data) : {};\n", opts.indents[2])
-	} else {
-		fmt.Fprintf(w, "\n%sconstructor(data?: any) {\n", opts.indents[1])
-		fmt.Fprintf(w, "%sconst d: any = (data && typeof data === 'object') ? ToObject(data) : {};\n", opts.indents[2])
+	ew := newErrWriter(w)
+	w = ew
+
+	ww := newTabScanner(w, ctx.Options.indents[1])
+	io.WriteString(ww, "\n")
+	if err = render(ww); err != nil {
+		return
 	}
+	io.WriteString(w, "\n")
+
+	return ew.err
+}
 
+func (s *Struct) RenderFields(opts *Options, w io.Writer) (err error) {
+	r := opts.renderer()
 	for _, f := range s.Fields {
-		if err = f.RenderCtor(w, opts); err != nil {
+		if err = r.RenderField(w, s, f); err != nil {
 			return
 		}
 	}
 
-	_, err = fmt.Fprintf(w, "%s}\n", opts.indents[1])
-
 	return
 }
 
+// RenderConstructor renders the class constructor, see ClassRenderer.
+func (s *Struct) RenderConstructor(opts *Options, w io.Writer) (err error) {
+	if opts.InterfaceOnly {
+		return
+	}
+	return classRenderer(opts).renderConstructor(w, s)
+}
+
+// RenderToObject renders the toObject and toJSON methods, see ClassRenderer.
 func (s *Struct) RenderToObject(opts *Options, w io.Writer) (err error) {
-	if opts.NoToObject || opts.InterfaceOnly {
+	if opts.InterfaceOnly {
 		return
 	}
+	return classRenderer(opts).renderToObject(w, s)
+}
+
+// renderCfg renders the ToObject cfg of s's fields, including the ones inherited from its embedded structs.
+func (s *Struct) renderCfg(opts *Options, w io.Writer) {
+	for _, c := range s.toObjectCfg(opts) {
+		fmt.Fprintf(w, "%scfg.%s = %s;\n", opts.indents[2], c.Name, c.Cfg)
+	}
+}
+
+// cfgEntry is the ToObject cfg of a field, Cfg is either a quoted cfg string or a conversion function.
+type cfgEntry struct {
+	Name, Cfg string
+}
 
-	if opts.ES6 {
-		fmt.Fprintf(w, "\n%stoObject() {\n", opts.indents[1])
-		fmt.Fprintf(w, "%sconst cfg = {};\n", opts.indents[2])
-	} else {
-		fmt.Fprintf(w, "\n%stoObject(): any {\n", opts.indents[1])
-		fmt.Fprintf(w, "%sconst cfg: any = {};\n", opts.indents[2])
+// toObjectCfg returns the ToObject cfg of s's fields that have one, including the ones inherited from its embedded structs.
+func (s *Struct) toObjectCfg(opts *Options) (out []cfgEntry) {
+	for _, e := range s.Embeds {
+		out = append(out, e.toObjectCfg(opts)...)
 	}
 
 	for _, f := range s.Fields {
-		t := f.Type(opts, true)
-		switch {
-		case t == "Date" && f.TsType != "number":
-			fmt.Fprintf(w, "%scfg.%s = 'string';\n", opts.indents[2], f.Name)
-		case t == "number":
-			fmt.Fprintf(w, "%scfg.%s = 'number';\n", opts.indents[2], f.Name)
+		if f.Override != nil && f.Override.ToObject != "" {
+			out = append(out, cfgEntry{f.Name, fmt.Sprintf("(v%s) => %s", TypeSuffix("any", opts.ES6, false), f.Override.ToObject)})
+		} else if cfg := f.toObjectCfg(opts); cfg != "" {
+			out = append(out, cfgEntry{f.Name, "'" + cfg + "'"})
 		}
 	}
-	_, err = fmt.Fprintf(w, "%sreturn ToObject(this, cfg);\n%s}\n", opts.indents[2], opts.indents[1])
+
 	return
 }
@@ -0,0 +1,216 @@
+package struct2ts
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is what the templates passed to RenderTemplate are executed with.
+type TemplateData struct {
+	Options *Options
+	Structs []*Struct
+
+	// Helpers are the names of the helper functions, they're empty if Options.NoHelpers is set.
+	Helpers []string
+
+	// Imports are the import statements added by the CustomTypescriptV2 implementations the template renders.
+	Imports []string
+}
+
+// Builtin templates that produce the same output as RenderTo, see DefaultTemplate.
+const (
+	ClassTemplate     = "class"
+	InterfaceTemplate = "interface"
+	ES6Template       = "es6"
+)
+
+// BuiltinTemplates are the sources of the built-in templates, they can be copied and customized.
+// They render the parts of the output with the built-in renderers, so they always match RenderTo.
+var BuiltinTemplates = map[string]string{
+	ClassTemplate:     classTemplate,
+	InterfaceTemplate: interfaceTemplate,
+	ES6Template:       es6Template,
+}
+
+// NewTemplate parses a template for RenderTemplate, it can use these funcs:
+//
+//	opts                 the *Options used to render.
+//	indent n             n levels of Options.Indent.
+//	helpers              the source of the helper functions (TS or ES6).
+//	prologue data        the imports, helpers and `// structs` header the built-in renderer starts with.
+//	epilogue data        the exports the built-in renderer ends with.
+//	part name struct     a part of a built-in class: init (the TypedInit interface), declared,
+//	                     constructor, factories, toObject, with, clone, equals or getters.
+//	pkg struct           the Go package path of the struct.
+//	extends struct sfx   the ` extends A, B` clause of the struct, sfx is appended to the names.
+//	custom struct        the output of the struct's CustomTypescript, if any.
+//	cfg struct           the toObject cfg entries (.Name and .Cfg, a JS value) of the struct, including its embeds.
+//	decl field           the declaration of the field, with its default value if the class has no constructor.
+//	declName field       the name the field is declared with (`readonly x?`).
+//	tsType field         the TS type of the field.
+//	initType field       the type the ctor accepts for the field, see Options.TypedInit.
+//	defaultValue field   the default value of the field.
+//	ctorExpr field       the expression the ctor assigns to the field from the input data `d`.
+//	cloneExpr field      the expression that deep copies `this.field`.
+//	notEquals field      the expression that checks if the field differs between `this` and `other`.
+func NewTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs(&Options{})).Parse(text)
+}
+
+// DefaultTemplate returns the built-in template that matches opts.
+func DefaultTemplate(opts *Options) *template.Template {
+	name := ClassTemplate
+	switch {
+	case opts.ES6:
+		name = ES6Template
+	case opts.InterfaceOnly:
+		name = InterfaceTemplate
+	}
+
+	return template.Must(NewTemplate(name, BuiltinTemplates[name]))
+}
+
+// RenderTemplate renders all the added types with tmpl rather than the built-in renderer,
+// tmpl is executed with a *TemplateData and has to be parsed with NewTemplate.
+func (s *StructToTS) RenderTemplate(w io.Writer, tmpl *template.Template) (err error) {
+	if err = s.prepare(); err != nil {
+		return
+	}
+
+	if tmpl, err = tmpl.Clone(); err != nil {
+		return
+	}
+
+	data := &TemplateData{Options: s.opts, Structs: s.structs}
+	if !s.opts.NoHelpers {
+		data.Helpers = helperNames
+	}
+
+	// the imports come first but only the ones of the custom output the template renders are used,
+	// so it's executed once to find out which custom output that is
+	tmpl = tmpl.Funcs(templateFuncs(s.opts))
+	if err = tmpl.Execute(ioutil.Discard, data); err != nil {
+		return
+	}
+	data.Imports = imports(s.structs)
+
+	return tmpl.Execute(w, data)
+}
+
+func templateFuncs(opts *Options) template.FuncMap {
+	return template.FuncMap{
+		"opts":   func() *Options { return opts },
+		"indent": func(n int) string { return strings.Repeat(opts.Indent, n) },
+		"helpers": func() string {
+			if opts.ES6 {
+				return es6_helpers
+			}
+			return ts_helpers
+		},
+
+		"prologue": func(data *TemplateData) (string, error) {
+			var buf bytes.Buffer
+			err := DefaultRenderer(opts).RenderPrologue(&buf, data.Structs)
+			return buf.String(), err
+		},
+		"epilogue": func(data *TemplateData) (string, error) {
+			var buf bytes.Buffer
+			err := DefaultRenderer(opts).RenderEpilogue(&buf, data.Structs)
+			return buf.String(), err
+		},
+		"part": func(name string, s *Struct) (string, error) {
+			fn, ok := templateParts[name]
+			if !ok {
+				return "", fmt.Errorf("unknown part %q", name)
+			}
+			var buf bytes.Buffer
+			err := fn(opts, &buf, s)
+			return buf.String(), err
+		},
+
+		"pkg":     func(s *Struct) string { return s.pkg },
+		"extends": func(s *Struct, suffix string) string { return s.extends(suffix) },
+		"custom": func(s *Struct) (string, error) {
+			var buf bytes.Buffer
+			err := s.RenderCustom(opts, &buf)
+			return buf.String(), err
+		},
+		"cfg": func(s *Struct) []cfgEntry { return s.toObjectCfg(opts) },
+
+		"decl": func(f *Field) (string, error) {
+			var buf bytes.Buffer
+			err := f.RenderTopLevel(&buf, opts)
+			return buf.String(), err
+		},
+		"declName":     func(f *Field) string { return f.declName(opts) },
+		"tsType":       func(f *Field) string { return f.Type(opts, false) },
+		"initType":     func(f *Field) string { return f.InitType(opts) },
+		"defaultValue": func(f *Field) string { return f.DefaultValue() },
+		"ctorExpr":     func(f *Field) string { return f.ctorExpr(opts) },
+		"cloneExpr":    func(f *Field) string { return f.cloneExpr(opts, "this."+f.Name, 0) },
+		"notEquals":    func(f *Field) string { return f.notEqualsExpr(opts) },
+	}
+}
+
+// templateParts are the parts of the built-in renderers the templates can use, see the part func.
+var templateParts = map[string]func(opts *Options, w io.Writer, s *Struct) error{
+	"init":        classPart((*ClassRenderer).renderInit),
+	"declared":    classPart((*ClassRenderer).renderDeclared),
+	"constructor": classPart((*ClassRenderer).renderConstructor),
+	"factories":   classPart((*ClassRenderer).renderFactories),
+	"toObject":    classPart((*ClassRenderer).renderToObject),
+	"with":        classPart((*ClassRenderer).renderWith),
+	"clone":       classPart((*ClassRenderer).renderClone),
+	"equals":      classPart((*ClassRenderer).renderEquals),
+	"getters":     classPart((*ClassRenderer).renderGetters),
+}
+
+func classPart(fn func(*ClassRenderer, io.Writer, *Struct) error) func(*Options, io.Writer, *Struct) error {
+	return func(opts *Options, w io.Writer, s *Struct) error { return fn(classRenderer(opts), w, s) }
+}
+
+const classTemplate = `{{ prologue . }}
+{{- range .Structs }}{{ template "struct" . }}
+
+{{ end -}}
+{{ epilogue . }}
+
+{{- define "struct" -}}
+// struct2ts:{{ pkg . }}.{{ .Name }}
+{{ part "init" . }}class {{ .Name }}{{ extends . "" }} {
+{{ range .Fields }}{{ decl . }}{{ end -}}
+{{ part "declared" . }}{{ part "constructor" . }}{{ part "factories" . }}{{ part "toObject" . }}
+{{- part "with" . }}{{ part "clone" . }}{{ part "equals" . }}{{ part "getters" . }}{{ custom . }}}
+{{- end }}`
+
+const interfaceTemplate = `{{ prologue . }}
+{{- range .Structs }}{{ template "struct" . }}
+
+{{ end -}}
+{{ epilogue . }}
+
+{{- define "struct" -}}
+// struct2ts:{{ pkg . }}.{{ .Name }}
+{{ if not opts.NoExports }}export {{ end }}interface {{ .Name }}{{ extends . "" }} {
+{{ range .Fields }}{{ decl . }}{{ end -}}
+{{ custom . }}}
+{{- end }}`
+
+const es6Template = `{{ prologue . }}
+{{- range .Structs }}{{ template "struct" . }}
+
+{{ end -}}
+{{ epilogue . }}
+
+{{- define "struct" -}}
+// struct2ts:{{ pkg . }}.{{ .Name }}
+{{ if not opts.InterfaceOnly -}}
+class {{ .Name }}{{ extends . "" }} {
+{{ part "constructor" . }}{{ part "factories" . }}{{ part "toObject" . }}{{ part "with" . }}{{ part "clone" . }}{{ part "equals" . }}
+{{- part "getters" . }}{{ custom . }}}
+{{- end }}
+{{- end }}`
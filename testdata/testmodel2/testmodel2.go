@@ -0,0 +1,8 @@
+package testmodel2
+
+import "github.com/OneOfOne/struct2ts/testdata/testmodel1"
+
+// Struct1 has the same name as testmodel1.Struct1.
+type Struct1 struct {
+	Other testmodel1.Struct1
+}